@@ -1,11 +1,16 @@
 package webapp
 
 import (
+	"context"
 	"github.com/justinas/alice"
 	"github.com/mbict/go-webapp/container"
+	"github.com/mbict/go-webapp/decoder"
 	"github.com/mbict/httprouter"
+	"io/fs"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 var DefaultEncoding = "application/json"
@@ -21,6 +26,7 @@ func WrapMiddleware(handler func(next http.Handler) http.Handler) Middleware {
 func mc(mw []Middleware) []alice.Constructor {
 	res := make([]alice.Constructor, len(mw))
 	for i, m := range mw {
+		m := m
 		res[i] = func(next http.Handler) http.Handler {
 			return m(next.ServeHTTP)
 		}
@@ -37,15 +43,28 @@ type Router interface {
 	Handle(method, path string, handle http.HandlerFunc, mw ...Middleware)
 	Handler(method, path string, handle http.Handler, mw ...Middleware)
 	Group(path string, mw ...Middleware) Router
+	Static(prefix string, fsys fs.FS, options ...StaticOption)
 
 	//Use(mw ...Middleware)
 }
 
 type API struct {
-	router     *httprouter.Router
-	config     *Config
-	container  container.Container
-	middleware alice.Chain
+	router      *httprouter.Router
+	config      *Config
+	container   container.Container
+	middleware  alice.Chain
+	routes      []*Route
+	hosts       []*hostRouter
+	versions    map[string]*versionConfig
+	dispatchers map[string]*versionDispatch
+	handlerCtx  *HandlerContext
+	options     *optionLayer
+	serveOnce   sync.Once
+	serve       http.HandlerFunc
+	serving     atomic.Bool
+
+	// Authorizer evaluates the permissions declared with Require.
+	Authorizer Authorizer
 
 	NotFound         http.HandlerFunc
 	MethodNotAllowed http.HandlerFunc
@@ -77,26 +96,67 @@ func (r *API) Handle(method, path string, handle http.HandlerFunc, mw ...Middlew
 }
 
 func (r *API) Handler(method, path string, handle http.Handler, mw ...Middleware) {
-	r.router.Handler(method, path, alice.New(mc(mw)...).Then(handle))
+	r.handle(r.router, &Route{Method: method, Path: path}, handle, mw)
+}
+
+// handle registers the route on the router, the router of the API or of a host.
+func (r *API) handle(router *httprouter.Router, route *Route, handle http.Handler, mw []Middleware) {
+	pattern, h := r.build(route, handle, mw)
+	router.Handler(route.Method, pattern, h)
+}
+
+// build adds the route to the routes of the API and returns the pattern for the router with the handler of the route.
+func (r *API) build(route *Route, handle http.Handler, mw []Middleware) (string, http.HandlerFunc) {
+	pattern, params := parsePattern(route.Path)
+	route.Params = params
+	return pattern, r.serveRoute(route, handle, mw)
+}
+
+// serveRoute adds the route to the routes of the API and returns the handler of the route.
+func (r *API) serveRoute(route *Route, handle http.Handler, mw []Middleware) http.HandlerFunc {
+	r.checkModifiable()
+
+	h := buildRoute(route, handle, mw)
+	match := matchPathTypes(route.Params)
+	path := route.Path
+
+	r.routes = append(r.routes, route)
+	return func(rw http.ResponseWriter, req *http.Request) {
+		//a value that does not match the type of the parameter does not match the route
+		if match != nil && !match(httprouter.ParamsFromContext(req.Context())) {
+			r.NotFound(rw, req)
+			return
+		}
+
+		route := MountPrefix(req.Context()) + path
+		info, req := WithRequestInfo(req)
+		info.Route = route
+		h.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), decoder.RouteKey, route)))
+	}
 }
 
 func (r *API) Group(path string, mw ...Middleware) Router {
 	return &group{
 		prefix:     path,
 		r:          r,
-		middleware: alice.New(mc(mw)...),
+		middleware: mw,
 	}
 }
 
 // global middleware
 func (r *API) Use(mw ...Middleware) {
+	r.checkModifiable()
 	for _, m := range mw {
+		m := m
 		r.middleware = r.middleware.Append(func(handler http.Handler) http.Handler {
 			return m(handler.ServeHTTP)
 		})
 	}
 }
 
+// Config is the configuration of the API.
+//
+// Deprecated: the API is configured with the options passed to New, e.g. WithDefaultOutputEncoding.
 type Config struct {
 	DefaultEncoding string
 
@@ -105,24 +165,26 @@ type Config struct {
 	//DisableNoContent bool
 }
 
-// New creates a new API instance.
-func New(c container.Container) *API {
-	//if c == nil {
-	//	c = c
-	//}
-
-	//if c.DefaultEncoding == "" {
-	//	c.DefaultEncoding = DefaultEncoding
-	//}
+// New creates a new API instance. The options configure the API wide behaviour, e.g. WithTracerProvider, and are the
+// defaults of the handlers registered on the API, handlers created with H inherit them.
+func New(c container.Container, options ...Option) *API {
+	if c != nil {
+		options = append([]Option{WithContainer(c)}, options...)
+	}
 
 	r := httprouter.New()
+	handlerCtx := newHandlerContext(options)
 
 	return &API{
 		router: r,
 		config: &Config{
-			DefaultEncoding: DefaultEncoding,
+			DefaultEncoding: handlerCtx.defaultEncoding,
 		},
 		container:        c,
+		handlerCtx:       handlerCtx,
+		options:          &optionLayer{options: options},
+		versions:         map[string]*versionConfig{},
+		dispatchers:      map[string]*versionDispatch{},
 		NotFound:         http.NotFound,
 		MethodNotAllowed: E(ErrMethodNotAllowed),
 		middleware:       alice.New(),
@@ -134,19 +196,43 @@ func (r *API) RequestHander() http.HandlerFunc {
 	r.router.MethodNotAllowed = r.MethodNotAllowed
 	//r.PanicHandler = r.PanicHandler
 
-	h := r.middleware.Then(r.router).ServeHTTP
+	var router http.Handler = r.router
+	if len(r.hosts) > 0 {
+		for _, host := range r.hosts {
+			host.router.NotFound = r.NotFound
+			host.router.MethodNotAllowed = r.MethodNotAllowed
+		}
+		router = http.HandlerFunc(r.routeHost)
+	}
+
+	h := r.middleware.Then(router).ServeHTTP
+
+	//the request span is started before the global middleware, so it covers all of it
+	if tp := r.handlerCtx.tracerProvider; tp != nil {
+		h = tracing(tp)(h)
+	}
 
 	return func(rw http.ResponseWriter, req *http.Request) {
 		//set default encoding, for content type if none is set
 		ct := req.Header.Get("Content-Type")
 		if len(ct) == 0 || strings.HasPrefix(ct, "*/*") {
-			req.Header.Set("Content-Type", r.config.DefaultEncoding)
+			req.Header.Set("Content-Type", r.handlerCtx.defaultEncoding)
 		}
 
 		//set default encoding for accept if none is set
 		ac := req.Header.Get("Accept")
 		if len(ac) == 0 || strings.HasPrefix(ac, "*/*") {
-			req.Header.Set("Accept", r.config.DefaultEncoding)
+			req.Header.Set("Accept", r.handlerCtx.defaultEncoding)
+		}
+
+		req = req.WithContext(context.WithValue(req.Context(), optionLayerKey{}, r.options))
+
+		if r.Authorizer != nil {
+			req = req.WithContext(context.WithValue(req.Context(), authorizerKey{}, r.Authorizer))
+		}
+
+		if proxies := r.handlerCtx.trustedProxies; proxies != nil {
+			req = req.WithContext(context.WithValue(req.Context(), decoder.TrustedProxiesKey, proxies))
 		}
 
 		h(rw, req)
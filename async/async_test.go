@@ -0,0 +1,258 @@
+package async
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mbict/go-webapp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reportRequest struct {
+	Name string `json:"name"`
+}
+
+type report struct {
+	Title string `json:"title"`
+}
+
+func TestExecutor(t *testing.T) {
+	release := make(chan struct{})
+
+	jobs := NewExecutor(NewMemoryStore(), "/jobs")
+
+	api := webapp.New(nil)
+	jobs.Routes(api)
+	api.Post("/reports", H(jobs, func(ctx context.Context, req reportRequest) (report, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return report{}, ctx.Err()
+		}
+
+		if req.Name == "" {
+			return report{}, errors.New("database is down")
+		}
+		return report{Title: "report " + req.Name}, nil
+	}))
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	status := func(location string) Job {
+		rec := serve(http.MethodGet, location, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var job Job
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+		return job
+	}
+
+	await := func(location string) Job {
+		for i := 0; i < 100; i++ {
+			if job := status(location); job.Status.Done() {
+				return job
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatal("job did not finish")
+		return Job{}
+	}
+
+	rec := serve(http.MethodPost, "/reports", `{"name":"sales"}`)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	location := rec.Header().Get("Location")
+	assert.True(t, strings.HasPrefix(location, "/jobs/"))
+	assert.Contains(t, []Status{Pending, Running}, status(location).Status)
+
+	failed := serve(http.MethodPost, "/reports", `{}`).Header().Get("Location")
+	canceled := serve(http.MethodPost, "/reports", `{"name":"stock"}`).Header().Get("Location")
+
+	//cancel the job
+	rec = serve(http.MethodDelete, canceled, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, Canceled, await(canceled).Status)
+
+	close(release)
+
+	job := await(location)
+	assert.Equal(t, Succeeded, job.Status)
+	assert.Equal(t, map[string]any{"title": "report sales"}, job.Result)
+
+	//internal errors are not exposed
+	job = await(failed)
+	assert.Equal(t, Failed, job.Status)
+	assert.Equal(t, "Internal Server Error", job.Error)
+
+	//finished jobs cannot be canceled
+	assert.Equal(t, http.StatusConflict, serve(http.MethodDelete, location, "").Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/jobs/unknown", "").Code)
+}
+
+func TestExecutorPanic(t *testing.T) {
+	jobs := NewExecutor(NewMemoryStore(), "/jobs")
+
+	id, err := jobs.Submit(context.Background(), func(ctx context.Context) (any, error) {
+		panic("boom")
+	})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		job, _, _ := jobs.store.Get(context.Background(), id)
+		return job.Status == Failed
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestExecutorMapError(t *testing.T) {
+	jobs := NewExecutor(NewMemoryStore(), "/jobs")
+
+	api := webapp.New(nil, webapp.MapError(sql.ErrNoRows, webapp.ErrorMapping{Status: http.StatusNotFound, Message: "report not found"}))
+	jobs.Routes(api)
+	api.Post("/reports", H(jobs, func(ctx context.Context, req reportRequest) (report, error) {
+		return report{}, fmt.Errorf("find report: %w", sql.ErrNoRows)
+	}))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodPost, "/reports", strings.NewReader(`{}`)))
+	location := rec.Header().Get("Location")
+
+	assert.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, location, nil))
+		return strings.Contains(rec.Body.String(), `"error":"report not found"`)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestExecutorLocation(t *testing.T) {
+	jobs := NewExecutor(NewMemoryStore(), "/jobs", WithLocation("/v1/jobs"))
+
+	reports := webapp.New(nil)
+	v1 := reports.Group("/v1")
+	jobs.Routes(v1)
+	v1.Post("/reports", H(jobs, func(ctx context.Context, req reportRequest) (report, error) {
+		return report{Title: req.Name}, nil
+	}))
+
+	api := webapp.New(nil)
+	api.Mount("/reporting", reports)
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodPost, "/reporting/v1/reports", strings.NewReader(`{}`)))
+	location := rec.Header().Get("Location")
+	assert.True(t, strings.HasPrefix(location, "/reporting/v1/jobs/"), location)
+
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, location, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestExecutorCancelFinished(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	jobs := NewExecutor(NewMemoryStore(), "/jobs")
+	api := webapp.New(nil)
+	jobs.Routes(api)
+
+	//the job ignores the cancellation and finishes
+	id, err := jobs.Submit(context.Background(), func(ctx context.Context) (any, error) {
+		close(started)
+		<-release
+		return "done", nil
+	})
+	assert.NoError(t, err)
+	<-started
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodDelete, "/jobs/"+id, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var job Job
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, Succeeded, job.Status)
+
+	job, _, _ = jobs.store.Get(context.Background(), id)
+	assert.Equal(t, Succeeded, job.Status)
+	assert.Equal(t, "done", job.Result)
+}
+
+func TestExecutorRoutesOnce(t *testing.T) {
+	jobs := NewExecutor(NewMemoryStore(), "/jobs")
+	api := webapp.New(nil)
+	jobs.Routes(api)
+
+	assert.PanicsWithValue(t, "async: the routes of the executor are already registered", func() {
+		jobs.Routes(api.Group("/v2"))
+	})
+}
+
+func TestExecutorConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	jobs := NewExecutor(NewMemoryStore(), "/jobs", WithConcurrency(1))
+
+	id, err := jobs.Submit(context.Background(), func(ctx context.Context) (any, error) {
+		<-release
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	//the executor is busy with the first job
+	_, err = jobs.Submit(context.Background(), func(ctx context.Context) (any, error) {
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, webapp.ErrServiceUnavailable)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		job, _, _ := jobs.store.Get(context.Background(), id)
+		return job.Status.Done()
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = jobs.Submit(context.Background(), func(ctx context.Context) (any, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() { WithConcurrency(0) })
+}
+
+func TestExecutorCancelTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	jobs := NewExecutor(NewMemoryStore(), "/jobs")
+	api := webapp.New(nil)
+	jobs.Routes(api)
+
+	//the job ignores the cancellation and does not finish
+	id, err := jobs.Submit(context.Background(), func(ctx context.Context) (any, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodDelete, "/jobs/"+id, nil).WithContext(ctx))
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+}
@@ -0,0 +1,275 @@
+// Package async runs handlers in the background, so long-running operations do not hold the HTTP connection.
+// A request is accepted with 202 Accepted and a Location pointing to the status resource of its job.
+//
+//	jobs := async.NewExecutor(async.NewMemoryStore(), "/jobs")
+//	jobs.Routes(api)
+//	api.Post("/reports", async.H(jobs, generateReport))
+package async
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/mbict/go-webapp"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status is the status of a job.
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Running   Status = "running"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"
+	Canceled  Status = "canceled"
+)
+
+// Done tells if the job has finished.
+func (s Status) Done() bool {
+	return s == Succeeded || s == Failed || s == Canceled
+}
+
+// Job is a handler call executed in the background.
+type Job struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+
+	// Result is the response of the handler, set when the job succeeded.
+	Result any `json:"result,omitempty"`
+
+	// Error is the public message of the error of a failed job.
+	Error string `json:"error,omitempty"`
+
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+type Option func(*Executor)
+
+// WithTTL sets how long finished jobs are kept, by default 24 hours.
+func WithTTL(ttl time.Duration) Option {
+	return func(e *Executor) {
+		e.ttl = ttl
+	}
+}
+
+// WithLocation sets the path the status resources are served at, for routes registered in a group or a version,
+// e.g. /v2/jobs. By default the prefix, the path of the routes registered on the API. The prefixes of the mounts a
+// request passed are added to the Location of its job.
+func WithLocation(location string) Option {
+	return func(e *Executor) {
+		e.location = location
+	}
+}
+
+// WithConcurrency sets the maximum number of jobs running at the same time, by default 100. Jobs submitted beyond it
+// are rejected with 503 Service Unavailable, so the client can retry later. Max must be at least 1.
+func WithConcurrency(max int) Option {
+	if max < 1 {
+		panic(fmt.Sprintf("async: invalid concurrency %d, must be at least 1", max))
+	}
+
+	return func(e *Executor) {
+		e.slots = make(chan struct{}, max)
+	}
+}
+
+// Executor runs jobs in the background and keeps their status in the store.
+//
+// Jobs can only be canceled by the executor running them, the running jobs are not shared through the store. When
+// several instances share the store, the cancellation of a job started by another instance is a 409 Conflict.
+type Executor struct {
+	store    Store
+	prefix   string
+	location string
+	ttl      time.Duration
+	now      func() time.Time
+	routed   bool
+
+	//slots limits the number of running jobs
+	slots chan struct{}
+
+	//running are the jobs of this executor that have not finished yet
+	running sync.Map
+}
+
+// running is a job in progress, done is closed once its final status is stored.
+type running struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewExecutor creates an executor with the status resources of its jobs under the prefix, see Routes.
+func NewExecutor(store Store, prefix string, options ...Option) *Executor {
+	e := &Executor{
+		store:    store,
+		prefix:   prefix,
+		location: prefix,
+		ttl:      24 * time.Hour,
+		now:      time.Now,
+		slots:    make(chan struct{}, 100),
+	}
+	for _, option := range options {
+		option(e)
+	}
+	return e
+}
+
+// H wraps the handle like webapp.H, but runs it in the background. The request is decoded and authorized before it
+// is accepted with 202 Accepted, the Location header points to the status resource of the job. The errors of the job
+// are mapped with the error mappings and error handler of the options, like the error responses of webapp.H.
+func H[T any, O any](e *Executor, handle webapp.Handle[T, O], options ...webapp.Option) http.HandlerFunc {
+	mapError := webapp.ErrorMapper(options...)
+
+	return webapp.H(func(ctx context.Context, req T) (webapp.AcceptedResponse, error) {
+		id, err := e.Submit(ctx, func(ctx context.Context) (any, error) {
+			res, err := handle(ctx, req)
+			if err != nil {
+				return nil, mapError(ctx, err)
+			}
+			return res, nil
+		})
+		if err != nil {
+			return webapp.AcceptedResponse{}, err
+		}
+		return webapp.NewAcceptedResponse(e.Location(ctx, id)), nil
+	}, options...)
+}
+
+// Location returns the path of the status resource of the job, for the Location of a request that submitted it.
+// It includes the prefixes of the mounts the request passed, see WithLocation.
+func (e *Executor) Location(ctx context.Context, id string) string {
+	return webapp.MountPrefix(ctx) + e.location + "/" + id
+}
+
+// Routes registers the status resource of the jobs, GET prefix/@id, and their cancellation, DELETE prefix/@id.
+// Routes registered in a group or version need WithLocation, so the Location of accepted jobs points to them.
+// The routes can only be registered once.
+func (e *Executor) Routes(r webapp.Router) {
+	if e.routed {
+		panic("async: the routes of the executor are already registered")
+	}
+	e.routed = true
+
+	r.Get(e.prefix+"/@id", webapp.H(e.status))
+	r.Delete(e.prefix+"/@id", webapp.H(e.cancel))
+}
+
+// Submit runs fn in the background and returns the id of its job. The context of fn keeps the values of ctx,
+// but is only canceled when the job is canceled. Jobs beyond the concurrency of the executor are rejected with
+// 503 Service Unavailable, see WithConcurrency.
+func (e *Executor) Submit(ctx context.Context, fn func(ctx context.Context) (any, error)) (string, error) {
+	select {
+	case e.slots <- struct{}{}:
+	default:
+		return "", webapp.ErrServiceUnavailable
+	}
+
+	now := e.now()
+	job := Job{ID: uuid.NewString(), Status: Pending, Created: now, Updated: now}
+	if err := e.store.Save(ctx, job, 0); err != nil {
+		<-e.slots
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	r := &running{cancel: cancel, done: make(chan struct{})}
+	e.running.Store(job.ID, r)
+
+	go e.run(ctx, r, job, fn)
+
+	return job.ID, nil
+}
+
+// run runs the job and stores its final status, it is the only writer of the job once it is submitted.
+func (e *Executor) run(ctx context.Context, r *running, job Job, fn func(ctx context.Context) (any, error)) {
+	defer func() {
+		e.running.Delete(job.ID)
+		r.cancel()
+		close(r.done)
+		<-e.slots
+	}()
+
+	//the job is stored again when it finishes, a failure to store it as running is not fatal
+	job.Status, job.Updated = Running, e.now()
+	_ = e.store.Save(ctx, job, 0)
+
+	res, err := call(ctx, fn)
+
+	//a job that finished before it was canceled keeps its result
+	job.Updated = e.now()
+	switch {
+	case err != nil && ctx.Err() != nil:
+		job.Status = Canceled
+	case err != nil:
+		job.Status, job.Error = Failed, message(err)
+	default:
+		job.Status, job.Result = Succeeded, res
+	}
+	_ = e.store.Save(context.WithoutCancel(ctx), job, e.ttl)
+}
+
+// call calls fn, a panic fails the job instead of the process.
+func call(ctx context.Context, fn func(ctx context.Context) (any, error)) (res any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// message returns the public message of the error, the details of internal errors that are not mapped are hidden.
+func message(err error) string {
+	if m, ok := err.(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	if sc, ok := err.(webapp.StatusCoder); ok && sc.StatusCode() < http.StatusInternalServerError {
+		return err.Error()
+	}
+	return http.StatusText(http.StatusInternalServerError)
+}
+
+type jobRequest struct {
+	ID string `path:"id"`
+}
+
+func (e *Executor) status(ctx context.Context, req jobRequest) (Job, error) {
+	job, ok, err := e.store.Get(ctx, req.ID)
+	if err != nil {
+		return Job{}, err
+	}
+	if !ok {
+		return Job{}, webapp.ErrNotFound
+	}
+	return job, nil
+}
+
+// cancel cancels a job that has not finished yet, finished jobs and jobs of other executors are a conflict.
+// It waits for the job to store its final status, a job that finished before it was canceled keeps its result.
+// A job that does not stop before the deadline of the request is a 504 Gateway Timeout, it is still canceled.
+func (e *Executor) cancel(ctx context.Context, req jobRequest) (Job, error) {
+	v, ok := e.running.Load(req.ID)
+	if !ok {
+		if _, err := e.status(ctx, req); err != nil {
+			return Job{}, err
+		}
+		return Job{}, webapp.ErrConflict
+	}
+
+	r := v.(*running)
+	r.cancel()
+
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return Job{}, webapp.ErrGatewayTimeout
+	}
+	return e.status(ctx, req)
+}
@@ -0,0 +1,81 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps the jobs of an executor.
+type Store interface {
+	// Save creates or updates the job, the job may be dropped after ttl. A zero ttl keeps the job.
+	Save(ctx context.Context, job Job, ttl time.Duration) error
+
+	// Get returns the job with the id, ok is false when there is no such job.
+	Get(ctx context.Context, id string) (job Job, ok bool, err error)
+}
+
+type memoryEntry struct {
+	job     Job
+	expires time.Time
+}
+
+// MemoryStore is an in-memory Store for a single process.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Save(_ context.Context, job Job, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	e := &memoryEntry{job: job}
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+	}
+	s.entries[job.ID] = e
+
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok || e.expired(s.now()) {
+		return Job{}, false, nil
+	}
+	return e.job, true, nil
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// sweep removes expired entries, at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(time.Minute)
+
+	for id, e := range s.entries {
+		if e.expired(now) {
+			delete(s.entries, id)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+)
+
+// APIKeyValidator validates an API key and returns the principal it belongs to.
+type APIKeyValidator func(ctx context.Context, key string) (*Principal, error)
+
+type apiKey struct {
+	name     string
+	inQuery  bool
+	validate APIKeyValidator
+}
+
+// APIKeyHeader authenticates requests with an API key read from the named header.
+func APIKeyHeader(name string, validate APIKeyValidator) Authenticator {
+	return &apiKey{name: name, validate: validate}
+}
+
+// APIKeyQuery authenticates requests with an API key read from the named query parameter.
+func APIKeyQuery(name string, validate APIKeyValidator) Authenticator {
+	return &apiKey{name: name, inQuery: true, validate: validate}
+}
+
+func (a *apiKey) Authenticate(req *http.Request) (*Principal, error) {
+	var key string
+	if a.inQuery {
+		key = req.URL.Query().Get(a.name)
+	} else {
+		key = req.Header.Get(a.name)
+	}
+
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	p, err := a.validate(req.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return withScheme(p, "APIKey"), nil
+}
+
+func (a *apiKey) Challenge() string {
+	in := "header"
+	if a.inQuery {
+		in = "query"
+	}
+	return `APIKey ` + in + `=` + strconv.Quote(a.name)
+}
+
+// APIKeys returns a validator that checks keys against a static key to subject map.
+// Keys are compared in constant time.
+func APIKeys(keys map[string]string) APIKeyValidator {
+	type entry struct {
+		hash    [32]byte
+		subject string
+	}
+
+	entries := make([]entry, 0, len(keys))
+	for key, subject := range keys {
+		entries = append(entries, entry{sha256.Sum256([]byte(key)), subject})
+	}
+
+	return func(_ context.Context, key string) (*Principal, error) {
+		given := sha256.Sum256([]byte(key))
+
+		var found *Principal
+		for _, e := range entries {
+			if subtle.ConstantTimeCompare(e.hash[:], given[:]) == 1 {
+				found = &Principal{Subject: e.subject}
+			}
+		}
+
+		if found == nil {
+			return nil, ErrInvalidCredentials
+		}
+		return found, nil
+	}
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/mbict/go-webapp"
+	"github.com/mbict/go-webapp/decoder"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request carries no credentials for its scheme.
+	ErrNoCredentials = errors.New("auth: no credentials")
+
+	// ErrInvalidCredentials is returned when the credentials are present but could not be verified.
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+)
+
+// Authenticator verifies the credentials of a single authentication scheme.
+type Authenticator interface {
+	// Authenticate returns the verified principal, ErrNoCredentials when the request has no credentials
+	// for this scheme, or an error wrapping ErrInvalidCredentials when the credentials are rejected.
+	// Other errors, e.g. a failing database, are not a credential problem and respond with 500.
+	Authenticate(req *http.Request) (*Principal, error)
+
+	// Challenge returns the value used in the WWW-Authenticate header.
+	Challenge() string
+}
+
+// Principal is the verified identity of the caller.
+// It implements decoder.Getter so it can be bound with the auth tag, e.g. `auth:"subject"` or `auth:"claims.roles"`.
+type Principal struct {
+	Scheme  string
+	Subject string
+	Claims  map[string]any
+}
+
+func (p *Principal) Get(key string) string {
+	if vs := p.Values(key); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+func (p *Principal) Values(key string) []string {
+	switch key {
+	case "subject":
+		return nonEmpty(p.Subject)
+	case "scheme":
+		return nonEmpty(p.Scheme)
+	}
+
+	if !strings.HasPrefix(key, "claims.") {
+		return nil
+	}
+
+	return claimValues(lookupClaim(p.Claims, strings.TrimPrefix(key, "claims.")))
+}
+
+func (p *Principal) Has(key string) bool {
+	switch key {
+	case "subject":
+		return p.Subject != ""
+	case "scheme":
+		return p.Scheme != ""
+	}
+
+	path, ok := strings.CutPrefix(key, "claims.")
+	return ok && lookupClaim(p.Claims, path) != nil
+}
+
+// Claim returns the raw claim found at the dotted path, e.g. "realm_access.roles".
+func (p *Principal) Claim(path string) (any, bool) {
+	v := lookupClaim(p.Claims, path)
+	return v, v != nil
+}
+
+func lookupClaim(claims map[string]any, path string) any {
+	var cur any = claims
+	for path != "" {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		//prefer the longest key, so claims with dots in their name still resolve
+		if v, ok := m[path]; ok {
+			return v
+		}
+
+		var key string
+		key, path, _ = strings.Cut(path, ".")
+		if cur, ok = m[key]; !ok {
+			return nil
+		}
+	}
+	return cur
+}
+
+func claimValues(v any) []string {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		res := make([]string, 0, len(v))
+		for _, e := range v {
+			res = append(res, claimValues(e)...)
+		}
+		return res
+	case []string:
+		return v
+	case string:
+		return []string{v}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case int:
+		return []string{strconv.Itoa(v)}
+	case int64:
+		return []string{strconv.FormatInt(v, 10)}
+	case interface{ String() string }:
+		return []string{v.String()}
+	}
+	return nil
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// withScheme returns a copy of the principal with the scheme, the principal of a verifier may be shared or cached.
+func withScheme(p *Principal, scheme string) *Principal {
+	c := *p
+	c.Scheme = scheme
+	return &c
+}
+
+// NewContext returns a copy of ctx carrying the principal.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, decoder.PrincipalKey, p)
+}
+
+// FromContext returns the principal stored in ctx, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(decoder.PrincipalKey).(*Principal)
+	return p, ok && p != nil
+}
+
+// Authenticate returns middleware that requires every request to authenticate with one of the authenticators.
+// The first authenticator that finds credentials in the request decides the outcome.
+func Authenticate(authenticators ...Authenticator) webapp.Middleware {
+	return middleware(authenticators, false)
+}
+
+// Optional returns middleware that authenticates the request when credentials are present,
+// but lets anonymous requests through. Invalid credentials are still rejected.
+func Optional(authenticators ...Authenticator) webapp.Middleware {
+	return middleware(authenticators, true)
+}
+
+func middleware(authenticators []Authenticator, optional bool) webapp.Middleware {
+	challenges := make([]string, 0, len(authenticators))
+	for _, a := range authenticators {
+		if c := a.Challenge(); c != "" {
+			challenges = append(challenges, c)
+		}
+	}
+
+	errorHandler := webapp.ErrorHandler()
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			for _, a := range authenticators {
+				p, err := a.Authenticate(req)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+
+				if err != nil {
+					errorHandler(authError(err, a.Challenge()))(rw, req)
+					return
+				}
+
+				next(rw, req.WithContext(NewContext(req.Context(), p)))
+				return
+			}
+
+			if optional {
+				next(rw, req)
+				return
+			}
+
+			errorHandler(&challengeError{webapp.ErrUnauthorized, challenges})(rw, req)
+		}
+	}
+}
+
+// authError maps the error of an authenticator, only rejected credentials are unauthorized.
+func authError(err error, challenge string) error {
+	switch {
+	case errors.Is(err, webapp.ErrForbidden):
+		return webapp.ErrForbidden
+	case !errors.Is(err, ErrInvalidCredentials) && !errors.Is(err, webapp.ErrUnauthorized):
+		return webapp.Error(err, http.StatusInternalServerError)
+	}
+
+	var challenges []string
+	if challenge != "" {
+		challenges = []string{challenge}
+	}
+	return &challengeError{webapp.ErrUnauthorized, challenges}
+}
+
+// challengeError is an unauthorized error that instructs the client how to authenticate.
+type challengeError struct {
+	webapp.StatusError
+	challenges []string
+}
+
+func (e *challengeError) Header() http.Header {
+	h := http.Header{}
+	if len(e.challenges) > 0 {
+		h.Set("WWW-Authenticate", strings.Join(e.challenges, ", "))
+	}
+	return h
+}
+
+func (e *challengeError) Unwrap() error {
+	return e.StatusError
+}
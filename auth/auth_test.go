@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/mbict/go-webapp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type authRequest struct {
+	Subject string   `auth:"subject"`
+	Scheme  string   `auth:"scheme"`
+	Roles   []string `auth:"claims.roles"`
+	Tenant  string   `auth:"claims.org.tenant"`
+}
+
+func echoHandler() http.HandlerFunc {
+	return webapp.H(func(ctx context.Context, req authRequest) (authRequest, error) {
+		return req, nil
+	})
+}
+
+func serve(mw webapp.Middleware, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req.Header.Set("Accept", "application/json")
+	mw(echoHandler())(rec, req)
+	return rec
+}
+
+func signHS256(t *testing.T, secret []byte, claims map[string]any) string {
+	input := segment(t, map[string]any{"alg": "HS256", "typ": "JWT"}) + "." + segment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	input := segment(t, map[string]any{"alg": "ES256", "kid": kid}) + "." + segment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func segment(t *testing.T, v any) string {
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestBasicAuthentication(t *testing.T) {
+	mw := Authenticate(Basic("test", BasicUsers(map[string]string{"john": "secret"})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("john", "secret")
+	rec := serve(mw, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"Subject":"john","Scheme":"Basic","Roles":null,"Tenant":""}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("john", "wrong")
+	rec = serve(mw, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Basic realm="test", charset="UTF-8"`, rec.Header().Get("WWW-Authenticate"))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("jane", "secret")
+	assert.Equal(t, http.StatusUnauthorized, serve(mw, req).Code)
+}
+
+func TestMissingCredentials(t *testing.T) {
+	mw := Authenticate(
+		Basic("test", BasicUsers(nil)),
+		APIKeyHeader("X-Api-Key", APIKeys(nil)),
+	)
+
+	rec := serve(mw, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, `Basic realm="test", charset="UTF-8", APIKey header="X-Api-Key"`, rec.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"message":"Unauthorized"}`, rec.Body.String())
+}
+
+func TestOptionalAuthentication(t *testing.T) {
+	mw := Optional(APIKeyQuery("api_key", APIKeys(map[string]string{"k3y": "service"})))
+
+	rec := serve(mw, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(mw, httptest.NewRequest(http.MethodGet, "/?api_key=k3y", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"Subject":"service","Scheme":"APIKey","Roles":null,"Tenant":""}`, rec.Body.String())
+
+	rec = serve(mw, httptest.NewRequest(http.MethodGet, "/?api_key=invalid", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestForbidden(t *testing.T) {
+	mw := Authenticate(APIKeyHeader("X-Api-Key", func(context.Context, string) (*Principal, error) {
+		return nil, webapp.ErrForbidden
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", "disabled")
+	rec := serve(mw, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestValidatorFailure(t *testing.T) {
+	mw := Authenticate(APIKeyHeader("X-Api-Key", func(context.Context, string) (*Principal, error) {
+		return nil, errors.New("database is down")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", "k3y")
+	rec := serve(mw, req)
+
+	//a failing validator is not a credential problem
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Empty(t, rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestSharedPrincipal(t *testing.T) {
+	shared := &Principal{Subject: "service"}
+	mw := Authenticate(Bearer("api", TokenVerifierFunc(func(ctx context.Context, token string) (*Principal, error) {
+		return shared, nil
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := serve(mw, req)
+
+	assert.JSONEq(t, `{"Subject":"service","Scheme":"Bearer","Roles":null,"Tenant":""}`, rec.Body.String())
+	assert.Empty(t, shared.Scheme)
+}
+
+func TestBearerHMAC(t *testing.T) {
+	secret := []byte("s3cr3t")
+	verifier := NewJWTVerifier(KeySet{{Key: secret}}, WithIssuer("issuer"), WithAudience("api"))
+	mw := Authenticate(Bearer("api", verifier))
+
+	token := signHS256(t, secret, map[string]any{
+		"sub":   "user-1",
+		"iss":   "issuer",
+		"aud":   []string{"api", "other"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"admin", "editor"},
+		"org":   map[string]any{"tenant": "acme"},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := serve(mw, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"Subject":"user-1","Scheme":"Bearer","Roles":["admin","editor"],"Tenant":"acme"}`, rec.Body.String())
+}
+
+func TestBearerRejectsInvalidTokens(t *testing.T) {
+	secret := []byte("s3cr3t")
+	verifier := NewJWTVerifier(KeySet{{Key: secret}}, WithIssuer("issuer"))
+
+	tests := map[string]string{
+		"expired":      signHS256(t, secret, map[string]any{"iss": "issuer", "exp": time.Now().Add(-time.Hour).Unix()}),
+		"not before":   signHS256(t, secret, map[string]any{"iss": "issuer", "nbf": time.Now().Add(time.Hour).Unix()}),
+		"issuer":       signHS256(t, secret, map[string]any{"iss": "other"}),
+		"wrong secret": signHS256(t, []byte("other"), map[string]any{"iss": "issuer"}),
+		"none":         segment(t, map[string]any{"alg": "none"}) + "." + segment(t, map[string]any{"iss": "issuer"}) + ".",
+		"malformed":    "abc",
+	}
+
+	for name, token := range tests {
+		_, err := verifier.Verify(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidCredentials, name)
+	}
+}
+
+func TestBearerNilPrincipal(t *testing.T) {
+	b := Bearer("api", TokenVerifierFunc(func(ctx context.Context, token string) (*Principal, error) {
+		return nil, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+
+	p, err := b.Authenticate(req)
+	assert.Nil(t, p)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestBearerECDSAFromJWKS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	jwks, err := json.Marshal(map[string]any{
+		"keys": []map[string]any{
+			{"kty": "oct", "kid": "enc", "use": "enc", "k": "c2VjcmV0"},
+			{
+				"kty": "EC",
+				"kid": "key-1",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+				"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	keys, err := ParseJWKS(jwks)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	verifier := NewJWTVerifier(keys)
+
+	p, err := verifier.Verify(context.Background(), signES256(t, key, "key-1", map[string]any{"sub": "user-2"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-2", p.Subject)
+
+	_, err = verifier.Verify(context.Background(), signES256(t, key, "unknown", map[string]any{"sub": "user-2"}))
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestPrincipalGetter(t *testing.T) {
+	p := &Principal{
+		Subject: "user",
+		Claims: map[string]any{
+			"scope":      []any{"read", "write"},
+			"admin":      true,
+			"level":      float64(3),
+			"nested":     map[string]any{"deep": map[string]any{"value": "x"}},
+			"dotted.key": "y",
+		},
+	}
+
+	assert.Equal(t, "user", p.Get("subject"))
+	assert.Equal(t, []string{"read", "write"}, p.Values("claims.scope"))
+	assert.Equal(t, "true", p.Get("claims.admin"))
+	assert.Equal(t, "3", p.Get("claims.level"))
+	assert.Equal(t, "x", p.Get("claims.nested.deep.value"))
+	assert.Equal(t, "y", p.Get("claims.dotted.key"))
+	assert.Equal(t, "", p.Get("claims.missing"))
+	assert.Equal(t, "", p.Get("unknown"))
+
+}
+
+func TestRBAC(t *testing.T) {
+	rbac := NewRBAC(map[string][]string{
+		"editor": {"res:read", "res:update"},
+		"admin":  {"res:*"},
+	}, WithRolesClaim("realm.roles"))
+
+	ctx := func(roles ...any) context.Context {
+		return NewContext(context.Background(), &Principal{Claims: map[string]any{"realm": map[string]any{"roles": roles}}})
+	}
+
+	assert.NoError(t, rbac.Authorize(ctx("editor"), []string{"res:read", "res:update"}, nil))
+	assert.NoError(t, rbac.Authorize(ctx("admin"), []string{"res:delete"}, nil))
+	assert.ErrorIs(t, rbac.Authorize(ctx("editor"), []string{"res:delete"}, nil), webapp.ErrForbidden)
+	assert.ErrorIs(t, rbac.Authorize(ctx(), []string{"res:read"}, nil), webapp.ErrForbidden)
+	assert.ErrorIs(t, rbac.Authorize(context.Background(), []string{"res:read"}, nil), webapp.ErrUnauthorized)
+}
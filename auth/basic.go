@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+)
+
+// BasicValidator validates a username and password pair.
+// When it returns a nil principal without an error, a principal with the username as subject is used.
+type BasicValidator func(ctx context.Context, username, password string) (*Principal, error)
+
+type basic struct {
+	realm    string
+	validate BasicValidator
+}
+
+// Basic authenticates requests with HTTP Basic authentication.
+func Basic(realm string, validate BasicValidator) Authenticator {
+	return &basic{realm: realm, validate: validate}
+}
+
+func (b *basic) Authenticate(req *http.Request) (*Principal, error) {
+	username, password, ok := req.BasicAuth()
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	p, err := b.validate(req.Context(), username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if p == nil {
+		p = &Principal{Subject: username}
+	}
+	return withScheme(p, "Basic"), nil
+}
+
+func (b *basic) Challenge() string {
+	return `Basic realm=` + strconv.Quote(b.realm) + `, charset="UTF-8"`
+}
+
+// BasicUsers returns a validator that checks credentials against a static username/password map.
+// Usernames and passwords are compared in constant time.
+func BasicUsers(users map[string]string) BasicValidator {
+	type entry struct {
+		username [32]byte
+		password [32]byte
+	}
+
+	entries := make([]entry, 0, len(users))
+	for username, password := range users {
+		entries = append(entries, entry{sha256.Sum256([]byte(username)), sha256.Sum256([]byte(password))})
+	}
+
+	return func(_ context.Context, username, password string) (*Principal, error) {
+		givenUsername := sha256.Sum256([]byte(username))
+		givenPassword := sha256.Sum256([]byte(password))
+
+		found := 0
+		for _, e := range entries {
+			found |= subtle.ConstantTimeCompare(e.username[:], givenUsername[:]) &
+				subtle.ConstantTimeCompare(e.password[:], givenPassword[:])
+		}
+
+		if found != 1 {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TokenVerifier verifies a bearer token and returns the principal it represents.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*Principal, error)
+}
+
+// TokenVerifierFunc is an adapter to use ordinary functions as a TokenVerifier.
+type TokenVerifierFunc func(ctx context.Context, token string) (*Principal, error)
+
+func (f TokenVerifierFunc) Verify(ctx context.Context, token string) (*Principal, error) {
+	return f(ctx, token)
+}
+
+type bearer struct {
+	realm    string
+	verifier TokenVerifier
+}
+
+// Bearer authenticates requests with a bearer token from the Authorization header.
+func Bearer(realm string, verifier TokenVerifier) Authenticator {
+	return &bearer{realm: realm, verifier: verifier}
+}
+
+func (b *bearer) Authenticate(req *http.Request) (*Principal, error) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	p, err := b.verifier.Verify(req.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	if p == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return withScheme(p, "Bearer"), nil
+}
+
+func (b *bearer) Challenge() string {
+	return `Bearer realm=` + strconv.Quote(b.realm)
+}
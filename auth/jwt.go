@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Key is a verification key for signed tokens.
+type Key struct {
+	// ID is matched against the kid header of the token, an empty ID matches any token.
+	ID string
+
+	// Algorithm restricts the key to a single signing algorithm, e.g. RS256. Empty allows all algorithms
+	// that fit the key type.
+	Algorithm string
+
+	// Key is a []byte secret for HMAC, an *rsa.PublicKey or an *ecdsa.PublicKey.
+	Key any
+}
+
+// KeySet is a collection of verification keys.
+type KeySet []Key
+
+func (ks KeySet) lookup(kid, alg string) (any, bool) {
+	for _, k := range ks {
+		if (k.ID == "" || kid == "" || k.ID == kid) && (k.Algorithm == "" || k.Algorithm == alg) && keyFits(k.Key, alg) {
+			return k.Key, true
+		}
+	}
+	return nil, false
+}
+
+type JWTOption func(*JWTVerifier)
+
+// WithIssuer requires the iss claim to match the issuer.
+func WithIssuer(issuer string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithAudience requires the aud claim to contain the audience.
+func WithAudience(audience string) JWTOption {
+	return func(v *JWTVerifier) {
+		v.audience = audience
+	}
+}
+
+// WithLeeway allows for clock skew when validating the exp and nbf claims.
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(v *JWTVerifier) {
+		v.leeway = leeway
+	}
+}
+
+// JWTVerifier verifies HMAC, RSA and ECDSA signed JSON Web Tokens.
+type JWTVerifier struct {
+	keys     KeySet
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+func NewJWTVerifier(keys KeySet, options ...JWTOption) *JWTVerifier {
+	v := &JWTVerifier{
+		keys: keys,
+		now:  time.Now,
+	}
+
+	for _, option := range options {
+		option(v)
+	}
+
+	return v
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+func (v *JWTVerifier) Verify(_ context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidToken("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalidToken("malformed header")
+	}
+
+	key, ok := v.keys.lookup(header.KeyID, header.Algorithm)
+	if !ok {
+		return nil, invalidToken("no key for algorithm " + header.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalidToken("malformed signature")
+	}
+
+	if !verifySignature(header.Algorithm, key, parts[0]+"."+parts[1], signature) {
+		return nil, invalidToken("signature mismatch")
+	}
+
+	claims := map[string]any{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalidToken("malformed claims")
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	sub, _ := claims["sub"].(string)
+	return &Principal{Subject: sub, Claims: claims}, nil
+}
+
+func (v *JWTVerifier) validateClaims(claims map[string]any) error {
+	now := v.now()
+
+	if exp, ok := claims["exp"].(float64); ok && now.After(unixTime(exp).Add(v.leeway)) {
+		return invalidToken("token expired")
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.leeway).Before(unixTime(nbf)) {
+		return invalidToken("token not yet valid")
+	}
+
+	if v.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.issuer {
+			return invalidToken("issuer mismatch")
+		}
+	}
+
+	if v.audience != "" {
+		found := false
+		for _, aud := range claimValues(claims["aud"]) {
+			if aud == v.audience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return invalidToken("audience mismatch")
+		}
+	}
+
+	return nil
+}
+
+func unixTime(f float64) time.Time {
+	return time.Unix(int64(f), 0)
+}
+
+func invalidToken(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidCredentials, reason)
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func hashForAlgorithm(alg string) (crypto.Hash, bool) {
+	if len(alg) != 5 {
+		return 0, false
+	}
+
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, true
+	case "384":
+		return crypto.SHA384, true
+	case "512":
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+func keyFits(key any, alg string) bool {
+	if _, ok := hashForAlgorithm(alg); !ok {
+		return false
+	}
+
+	switch k := key.(type) {
+	case []byte:
+		return alg[:2] == "HS"
+	case *rsa.PublicKey:
+		return alg[:2] == "RS" || alg[:2] == "PS"
+	case *ecdsa.PublicKey:
+		return alg[:2] == "ES" && k.Curve.Params().BitSize == ecdsaBits(alg)
+	}
+	return false
+}
+
+func ecdsaBits(alg string) int {
+	switch alg {
+	case "ES256":
+		return 256
+	case "ES384":
+		return 384
+	}
+	return 521
+}
+
+func verifySignature(alg string, key any, signingInput string, signature []byte) bool {
+	hash, ok := hashForAlgorithm(alg)
+	if !ok {
+		return false
+	}
+
+	if secret, ok := key.([]byte); ok {
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg[:2] == "PS" {
+			return rsa.VerifyPSS(k, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+		return rsa.VerifyPKCS1v15(k, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	}
+	return false
+}
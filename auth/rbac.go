@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"github.com/mbict/go-webapp"
+	"strings"
+)
+
+// RBAC is a role based webapp.Authorizer. The roles of the principal are read from a claim
+// and every role grants a set of permissions.
+//
+// A permission ending in "*" grants all permissions with that prefix, e.g. "res:*" grants "res:delete".
+type RBAC struct {
+	roles map[string][]string
+	claim string
+}
+
+type RBACOption func(*RBAC)
+
+// WithRolesClaim sets the dotted claim path the roles are read from, defaults to "roles".
+func WithRolesClaim(claim string) RBACOption {
+	return func(r *RBAC) {
+		r.claim = claim
+	}
+}
+
+// NewRBAC creates a role based authorizer from a role to permissions map.
+func NewRBAC(roles map[string][]string, options ...RBACOption) *RBAC {
+	r := &RBAC{
+		roles: roles,
+		claim: "roles",
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+
+	return r
+}
+
+// Authorize grants access when the roles of the principal together hold all the permissions.
+func (r *RBAC) Authorize(ctx context.Context, permissions []string, _ any) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return webapp.ErrUnauthorized
+	}
+
+	roles := p.Values("claims." + r.claim)
+
+	for _, permission := range permissions {
+		if !r.granted(roles, permission) {
+			return webapp.ErrForbidden
+		}
+	}
+	return nil
+}
+
+func (r *RBAC) granted(roles []string, permission string) bool {
+	for _, role := range roles {
+		for _, granted := range r.roles[role] {
+			if granted == permission || (strings.HasSuffix(granted, "*") && strings.HasPrefix(permission, granted[:len(granted)-1])) {
+				return true
+			}
+		}
+	}
+	return false
+}
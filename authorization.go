@@ -0,0 +1,145 @@
+package webapp
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+// Authorizer decides if the caller is allowed to perform the required permissions on the decoded request.
+type Authorizer interface {
+	Authorize(ctx context.Context, permissions []string, request any) error
+}
+
+// AuthorizerFunc is an adapter to use ordinary functions as an Authorizer.
+type AuthorizerFunc func(ctx context.Context, permissions []string, request any) error
+
+func (f AuthorizerFunc) Authorize(ctx context.Context, permissions []string, request any) error {
+	return f(ctx, permissions, request)
+}
+
+// Policy creates an Authorizer that receives the decoded request of type T.
+// Requests of any other type are denied.
+func Policy[T any](policy func(ctx context.Context, permissions []string, request T) error) Authorizer {
+	return AuthorizerFunc(func(ctx context.Context, permissions []string, request any) error {
+		req, ok := request.(T)
+		if !ok {
+			return ErrForbidden
+		}
+		return policy(ctx, permissions, req)
+	})
+}
+
+// AllOf creates an Authorizer that only grants access when all the authorizers do.
+func AllOf(authorizers ...Authorizer) Authorizer {
+	return AuthorizerFunc(func(ctx context.Context, permissions []string, request any) error {
+		for _, a := range authorizers {
+			if err := a.Authorize(ctx, permissions, request); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type permissionsKey struct{}
+type authorizerKey struct{}
+type authorizedKey struct{}
+
+// Require declares the permissions needed to call a route, it can be passed to a route, a group or Use.
+// The permissions are evaluated with the Authorizer right before the handler is called. Handlers created with H
+// evaluate them after the request is decoded and the Authorizer receives the decoded request, for any other handler
+// it receives the *http.Request.
+func Require(permissions ...string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			perms := append(RequiredPermissions(ctx), permissions...)
+			next(rw, req.WithContext(context.WithValue(ctx, permissionsKey{}, perms)))
+		}
+	}
+}
+
+// requireCode is the code of the middleware returned by Require, shared by all of them.
+var requireCode = reflect.ValueOf(Require()).Pointer()
+
+// requiredPermissions returns the permissions declared with Require in the middleware of a route and its groups,
+// to describe the route. Only the middleware returned by Require is run, it has no side effects.
+// The permissions are enforced from the request, this includes Require passed to Use or wrapped by other middleware.
+func requiredPermissions(mw []Middleware) []string {
+	var permissions []string
+	var h http.HandlerFunc = func(_ http.ResponseWriter, req *http.Request) {
+		permissions = RequiredPermissions(req.Context())
+	}
+
+	for i := len(mw) - 1; i >= 0; i-- {
+		if reflect.ValueOf(mw[i]).Pointer() == requireCode {
+			h = mw[i](h)
+		}
+	}
+
+	h(nil, (&http.Request{}).WithContext(context.Background()))
+	return permissions
+}
+
+// authorizeRequest evaluates the required permissions before calling a handler that does not evaluate them itself.
+// The handler is told how many of the permissions are granted, a handler created with H wrapped by it only
+// evaluates them again when more permissions are required by then.
+func authorizeRequest(next http.Handler) http.HandlerFunc {
+	contexts := newHandlerContexts(nil)
+
+	return func(rw http.ResponseWriter, req *http.Request) {
+		permissions := RequiredPermissions(req.Context())
+		if len(permissions) <= authorizedPermissions(req.Context()) {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		handlerCtx := contexts.of(req)
+		if err := handlerCtx.authorize(req.Context(), permissions, req); err != nil {
+			setFailure(req, AuthorizationFailure)
+			handlerCtx.handleError(err, rw, req)
+			return
+		}
+		next.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), authorizedKey{}, len(permissions))))
+	}
+}
+
+// RequiredPermissions returns all the permissions declared with Require for the current request.
+func RequiredPermissions(ctx context.Context) []string {
+	perms, _ := ctx.Value(permissionsKey{}).([]string)
+	return perms[:len(perms):len(perms)]
+}
+
+// authorizedPermissions returns the number of required permissions that are already granted for the request.
+func authorizedPermissions(ctx context.Context) int {
+	n, _ := ctx.Value(authorizedKey{}).(int)
+	return n
+}
+
+func authorizerFromContext(ctx context.Context) Authorizer {
+	a, _ := ctx.Value(authorizerKey{}).(Authorizer)
+	return a
+}
+
+// authorizing is the handler created with H, it evaluates the required permissions itself once the request is
+// decoded. H returns its ServeHTTP method as http.HandlerFunc.
+type authorizing http.HandlerFunc
+
+func (h authorizing) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	h(rw, req)
+}
+
+// authorizingCode is the code of the ServeHTTP method value of authorizing, shared by all the handlers created with H.
+var authorizingCode = reflect.ValueOf(authorizing(nil).ServeHTTP).Pointer()
+
+// authorizesItself reports if the handler is created with H and not wrapped since.
+func authorizesItself(h http.Handler) bool {
+	switch h := h.(type) {
+	case authorizing:
+		return true
+	case http.HandlerFunc:
+		return reflect.ValueOf(h).Pointer() == authorizingCode
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package webapp
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type deleteRequest struct {
+	Id    string `path:"id"`
+	Owner string `header:"X-Owner"`
+}
+
+func TestRequireEvaluatesAuthorizerAfterBinding(t *testing.T) {
+	var seen []string
+	policy := Policy(func(ctx context.Context, permissions []string, req deleteRequest) error {
+		seen = permissions
+		if req.Owner != "me" {
+			return ErrForbidden
+		}
+		return nil
+	})
+
+	api := New(nil)
+	group := api.Group("/res", Require("res:read"))
+	group.Delete("/@id", H(func(ctx context.Context, req deleteRequest) (*Empty, error) {
+		return nil, nil
+	}, DefaultOptions.Add(WithAuthorizer(policy))...), Require("res:delete"))
+
+	req := httptest.NewRequest(http.MethodDelete, "/res/1", nil)
+	req.Header.Set("X-Owner", "me")
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, []string{"res:read", "res:delete"}, seen)
+
+	req = httptest.NewRequest(http.MethodDelete, "/res/1", nil)
+	req.Header.Set("X-Owner", "someone else")
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRequireUsesAPIAuthorizer(t *testing.T) {
+	called := false
+	api := New(nil)
+	api.Authorizer = AuthorizerFunc(func(ctx context.Context, permissions []string, request any) error {
+		called = true
+		assert.Equal(t, deleteRequest{Id: "1"}, request)
+		return nil
+	})
+	api.Delete("/res/@id", H(func(ctx context.Context, req deleteRequest) (*Empty, error) {
+		return nil, nil
+	}), Require("res:delete"))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodDelete, "/res/1", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.True(t, called)
+}
+
+func TestRequireDeniesWithoutAuthorizer(t *testing.T) {
+	api := New(nil)
+	api.Delete("/res/@id", H(func(ctx context.Context, req deleteRequest) (*Empty, error) {
+		return nil, nil
+	}), Require("res:delete"))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodDelete, "/res/1", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRoutesListsPermissions(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	api := New(nil)
+	api.Get("/res", noop)
+	admin := api.Group("/admin", Require("admin"))
+	admin.Delete("/res/@id", noop, Require("res:delete"))
+	admin.Group("/users").Post("", noop, Require("users:create", "users:invite"))
+
+	assert.Equal(t, []Route{
+		{Method: http.MethodGet, Path: "/res"},
+		{Method: http.MethodDelete, Path: "/admin/res/@id", Params: []PathParam{{Name: "id"}}, Permissions: []string{"admin", "res:delete"}},
+		{Method: http.MethodPost, Path: "/admin/users", Permissions: []string{"admin", "users:create", "users:invite"}},
+	}, api.Routes())
+}
+
+func TestRequireEnforcedForPlainHandlers(t *testing.T) {
+	var request any
+	api := New(nil)
+	api.Authorizer = AuthorizerFunc(func(ctx context.Context, permissions []string, req any) error {
+		request = req
+		return ErrForbidden
+	})
+
+	api.Delete("/admin/x", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("deleted"))
+	}, Require("admin"))
+	api.Get("/admin/error", E(ErrNotFound), Require("admin"))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodDelete, "/admin/x", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "deleted")
+	assert.IsType(t, &http.Request{}, request)
+
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/admin/error", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.IsType(t, &http.Request{}, request)
+}
+
+func TestRequireOutsideTheRoute(t *testing.T) {
+	var calls []any
+	api := New(nil)
+	api.Authorizer = AuthorizerFunc(func(ctx context.Context, permissions []string, req any) error {
+		calls = append(calls, req)
+		return ErrForbidden
+	})
+
+	//middleware combining Require with other middleware
+	combined := func(next http.HandlerFunc) http.HandlerFunc {
+		return Require("admin")(next)
+	}
+
+	deleted := func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("deleted"))
+	}
+	api.Delete("/combined", deleted, combined)
+	api.Group("/use").Delete("/x", deleted)
+	api.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			if strings.HasPrefix(req.URL.Path, "/use") {
+				Require("admin")(next)(rw, req)
+				return
+			}
+			next(rw, req)
+		}
+	})
+
+	for _, path := range []string{"/combined", "/use/x"} {
+		calls = nil
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, httptest.NewRequest(http.MethodDelete, path, nil))
+
+		assert.Equal(t, http.StatusForbidden, rec.Code, path)
+		assert.NotContains(t, rec.Body.String(), "deleted", path)
+		assert.Len(t, calls, 1, path)
+	}
+}
+
+func TestRequireWrappedHandler(t *testing.T) {
+	var calls []any
+	api := New(nil)
+	api.Authorizer = AuthorizerFunc(func(ctx context.Context, permissions []string, req any) error {
+		calls = append(calls, req)
+		return nil
+	})
+
+	h := H(func(ctx context.Context, req deleteRequest) (*Empty, error) {
+		return nil, nil
+	})
+	api.Delete("/res/@id", func(rw http.ResponseWriter, req *http.Request) {
+		h(rw, req)
+	}, Require("res:delete"))
+	api.Delete("/typed/@id", h, Require("res:delete"))
+
+	//a wrapped handler is authorized once, before it is called
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodDelete, "/res/1", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Len(t, calls, 1)
+	assert.IsType(t, &http.Request{}, calls[0])
+
+	calls = nil
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodDelete, "/typed/1", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, []any{deleteRequest{Id: "1"}}, calls)
+}
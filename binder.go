@@ -1,6 +1,13 @@
 package webapp
 
-import "github.com/mbict/go-webapp/decoder"
+import (
+	"fmt"
+	"github.com/mbict/go-webapp/decoder"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
 
 const defaultTag = "default"
 const pathTag = "path"
@@ -8,45 +15,233 @@ const queryTag = "query"
 const headerTag = "header"
 const cookieTag = "cookie"
 const requestTag = "request"
+const authTag = "auth"
+const ctxTag = "ctx"
+const envTag = "env"
+const hostTag = "host"
+const bindTag = "bind"
 
-type decoderFactory func(v any, tag string) (decoder.Decode, error)
-
-var defaultDecodeBinders = []struct {
-	tag     string
-	factory decoderFactory
-}{
-	{
-		tag:     headerTag,
-		factory: decoder.NewHeaderDecoder,
-	},
-	{
-		tag:     queryTag,
-		factory: decoder.NewQueryDecoder,
-	},
-	{
-		tag:     cookieTag,
-		factory: decoder.NewCookieDecoder,
-	},
-	{
-		tag:     pathTag,
-		factory: decoder.NewPathDecoder,
-	},
-	{
-		tag:     requestTag,
-		factory: decoder.NewRequestDecoder,
-	},
+// Source is a tag that binds request values, e.g. `query:"name"` binds the name query parameter.
+type Source struct {
+	Tag    string
+	Getter decoder.GetterFunc
 }
 
+// sources are decoded in order, by default the environment first so request values take precedence over deployment
+// defaults. WithSourceOrder changes the order per handler, listing env after a request source lets the environment
+// overwrite the request values.
+var (
+	sourcesMu sync.RWMutex
+	sources   = []Source{
+		{Tag: envTag, Getter: decoder.EnvValues},
+		{Tag: headerTag, Getter: decoder.HeaderValues},
+		{Tag: queryTag, Getter: decoder.QueryValues},
+		{Tag: cookieTag, Getter: decoder.CookieValues},
+		{Tag: pathTag, Getter: decoder.PathValues},
+		{Tag: hostTag, Getter: decoder.HostValues},
+		{Tag: requestTag, Getter: decoder.RequestValues},
+		{Tag: ctxTag, Getter: decoder.ContextValues},
+		{Tag: authTag, Getter: decoder.AuthValues},
+	}
+)
+
+// RegisterSource registers a tag that binds the values of the getter, registering an existing tag replaces it.
+// Sources are decoded in the order of registration, so later sources overwrite the values of earlier ones,
+// use WithSourceOrder to change the precedence. Handlers compile their decoders once, so register the sources
+// before the handlers are created.
+func RegisterSource(tag string, getter decoder.GetterFunc) {
+	if tag == defaultTag || tag == bindTag {
+		panic("webapp: the " + tag + " tag is reserved")
+	}
+
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+
+	for i := range sources {
+		if sources[i].Tag == tag {
+			sources[i].Getter = getter
+			return
+		}
+	}
+	sources = append(sources, Source{Tag: tag, Getter: getter})
+}
+
+// Sources returns the registered sources in the order they are decoded.
+func Sources() []Source {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	return append([]Source(nil), sources...)
+}
+
+func sourceGetter(tag string) decoder.GetterFunc {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	for _, s := range sources {
+		if s.Tag == tag {
+			return s.Getter
+		}
+	}
+	return nil
+}
+
+// BuildArgumentsBinder builds the decoder for the registered sources and the bind tag used in v.
+// Fields with a bind tag are decoded last, so their fallback chain takes precedence.
 func BuildArgumentsBinder(v any) (Decoder, error) {
 	res := decoders{}
-	for _, c := range defaultDecodeBinders {
-		if hasTag(v, c.tag) {
-			dec, err := c.factory(v, c.tag)
+	for _, s := range Sources() {
+		if hasTag(v, s.Tag) {
+			dec, err := decoder.NewGetterDecoder(v, s.Tag, s.Getter)
 			if err != nil {
 				return nil, err
 			}
-			res = append(res, dec)
+			res = append(res, argumentDecoder{tag: s.Tag, span: "decode " + s.Tag, decode: dec})
+		}
+	}
+
+	if hasTag(v, bindTag) {
+		if err := validateBindTags(reflect.TypeOf(v)); err != nil {
+			return nil, err
+		}
+
+		dec, err := decoder.NewGetterDecoder(v, bindTag, newBindGetter)
+		if err != nil {
+			return nil, err
 		}
+		res = append(res, argumentDecoder{tag: bindTag, span: "decode " + bindTag, decode: dec})
 	}
 	return &res, nil
 }
+
+// binding is a single source of a bind tag, query:api_key.
+type binding struct {
+	source string
+	key    string
+}
+
+var bindKeys sync.Map
+
+// parseBindKey parses the fallback chain of a bind tag, header:X-Api-Key|query:api_key.
+func parseBindKey(key string) []binding {
+	if b, ok := bindKeys.Load(key); ok {
+		return b.([]binding)
+	}
+
+	var res []binding
+	for _, part := range strings.Split(key, "|") {
+		source, k, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		res = append(res, binding{source: source, key: k})
+	}
+
+	bindKeys.Store(key, res)
+	return res
+}
+
+// bindKeysOf returns the keys of the source in the fallback chain of the bind tag of the field.
+func bindKeysOf(source string) decoder.TagKeys {
+	return func(f reflect.StructField) []string {
+		tag, ok := f.Tag.Lookup(bindTag)
+		if !ok {
+			return nil
+		}
+
+		key, _, _ := strings.Cut(tag, ",")
+
+		var res []string
+		for _, b := range parseBindKey(key) {
+			if b.source == source {
+				res = append(res, b.key)
+			}
+		}
+		return res
+	}
+}
+
+func validateBindTags(t reflect.Type) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // skip unexported fields
+		}
+
+		if tag, ok := f.Tag.Lookup(bindTag); ok {
+			key, _, _ := strings.Cut(tag, ",")
+			bindings := parseBindKey(key)
+			if len(bindings) != strings.Count(key, "|")+1 {
+				return fmt.Errorf("webapp: invalid bind tag %q on field %s, expected source:key|source:key", tag, f.Name)
+			}
+
+			for _, b := range bindings {
+				if sourceGetter(b.source) == nil {
+					return fmt.Errorf("webapp: unknown source %q in bind tag of field %s", b.source, f.Name)
+				}
+			}
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() == reflect.Struct {
+			if err := validateBindTags(ft); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bindGetter resolves the keys of a bind tag, the first source in the chain that has the key provides the values.
+type bindGetter struct {
+	req     *http.Request
+	getters map[string]decoder.Getter
+}
+
+func newBindGetter(req *http.Request) decoder.Getter {
+	return &bindGetter{req: req, getters: map[string]decoder.Getter{}}
+}
+
+func (b *bindGetter) lookup(key string) (decoder.Getter, string) {
+	for _, bind := range parseBindKey(key) {
+		g, ok := b.getters[bind.source]
+		if !ok {
+			if getter := sourceGetter(bind.source); getter != nil {
+				g = getter(b.req)
+			}
+			b.getters[bind.source] = g
+		}
+
+		if g != nil && g.Has(bind.key) {
+			return g, bind.key
+		}
+	}
+	return nil, ""
+}
+
+func (b *bindGetter) Get(key string) string {
+	if g, k := b.lookup(key); g != nil {
+		return g.Get(k)
+	}
+	return ""
+}
+
+func (b *bindGetter) Values(key string) []string {
+	if g, k := b.lookup(key); g != nil {
+		return g.Values(k)
+	}
+	return nil
+}
+
+func (b *bindGetter) Has(key string) bool {
+	g, _ := b.lookup(key)
+	return g != nil
+}
@@ -0,0 +1,154 @@
+package webapp
+
+import (
+	"context"
+	"fmt"
+	"github.com/mbict/go-webapp/decoder"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+type bindRequest struct {
+	APIKey string `bind:"header:X-Api-Key|query:api_key|cookie:api_key"`
+	Name   string `query:"name" header:"X-Name"`
+	Tenant string `tenant:"id"`
+}
+
+func TestBindFallbackChain(t *testing.T) {
+	api := New(nil)
+	api.Get("/res", H(func(ctx context.Context, req bindRequest) (string, error) {
+		return req.APIKey, nil
+	}))
+
+	serve := func(req *http.Request) string {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec.Body.String()
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/res?api_key=query", nil)
+	req.Header.Set("X-Api-Key", "header")
+	assert.Equal(t, "\"header\"\n", serve(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/res?api_key=query", nil)
+	req.AddCookie(&http.Cookie{Name: "api_key", Value: "cookie"})
+	assert.Equal(t, "\"query\"\n", serve(req))
+
+	req = httptest.NewRequest(http.MethodGet, "/res", nil)
+	req.AddCookie(&http.Cookie{Name: "api_key", Value: "cookie"})
+	assert.Equal(t, "\"cookie\"\n", serve(req))
+}
+
+func TestBindUnknownSource(t *testing.T) {
+	assert.PanicsWithError(t, `webapp: unknown source "body" in bind tag of field Key`, func() {
+		H(func(ctx context.Context, req struct {
+			Key string `bind:"header:X-Key|body:key"`
+		}) (string, error) {
+			return "", nil
+		})
+	})
+}
+
+func TestRegisterSource(t *testing.T) {
+	RegisterSource("tenant", func(req *http.Request) decoder.Getter {
+		return decoder.MapGetter{"id": {req.Host}}
+	})
+
+	assert.Equal(t, "tenant", Sources()[len(Sources())-1].Tag)
+	assert.Panics(t, func() { RegisterSource(bindTag, nil) })
+
+	api := New(nil)
+	api.Get("/res", H(func(ctx context.Context, req bindRequest) (string, error) {
+		return req.Tenant, nil
+	}))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "http://acme.example.com/res", nil))
+
+	assert.Equal(t, "\"acme.example.com\"\n", rec.Body.String())
+}
+
+func TestSourceOrder(t *testing.T) {
+	handle := func(ctx context.Context, req bindRequest) (string, error) {
+		return req.Name, nil
+	}
+
+	req := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/res?name=query", nil)
+		req.Header.Set("X-Name", "header")
+		return req
+	}
+
+	//registration order, query overwrites header
+	api := New(nil)
+	api.Get("/res", H(handle))
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req())
+	assert.Equal(t, "\"query\"\n", rec.Body.String())
+
+	//api order
+	api = New(nil, WithSourceOrder("query", "header"))
+	api.Get("/res", H(handle))
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, req())
+	assert.Equal(t, "\"header\"\n", rec.Body.String())
+
+	//handler order takes precedence
+	api.Get("/handler", H(handle, DefaultOptions.Add(WithSourceOrder("header", "query"))...))
+	r := req()
+	r.URL.Path = "/handler"
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, r)
+	assert.Equal(t, "\"query\"\n", rec.Body.String())
+}
+
+func TestBindContextAndEnv(t *testing.T) {
+	t.Setenv("WEBAPP_TEST_PAGE_SIZE", "50")
+
+	type listRequest struct {
+		Tenant   string `ctx:"tenant"`
+		PageSize int    `env:"WEBAPP_TEST_PAGE_SIZE" query:"page_size"`
+	}
+
+	api := New(nil)
+	api.Get("/res", H(func(ctx context.Context, req listRequest) (string, error) {
+		return fmt.Sprintf("%s %d", req.Tenant, req.PageSize), nil
+	}))
+
+	serve := func(req *http.Request) string {
+		req = req.WithContext(context.WithValue(req.Context(), decoder.ContextKey("tenant"), "acme"))
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "\"acme 50\"\n", serve(httptest.NewRequest(http.MethodGet, "/res", nil)))
+
+	//the environment is decoded first, so request values take precedence
+	assert.Equal(t, "\"acme 10\"\n", serve(httptest.NewRequest(http.MethodGet, "/res?page_size=10", nil)))
+}
+
+func TestBindRequestClientIPAndRoute(t *testing.T) {
+	type infoRequest struct {
+		ClientIP netip.Addr `request:"client-ip"`
+		Route    string     `request:"route"`
+	}
+
+	api := New(nil, WithTrustedProxies("10.0.0.0/8"))
+	api.Get("/res/@id", H(func(ctx context.Context, req infoRequest) (string, error) {
+		return req.ClientIP.String() + " " + req.Route, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/res/1", nil)
+	req.RemoteAddr = "10.1.2.3:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 10.0.0.2")
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+	assert.Equal(t, "\"198.51.100.1 /res/@id\"\n", rec.Body.String())
+
+	assert.Panics(t, func() { WithTrustedProxies("not-an-ip") })
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const decoderPath = "github.com/mbict/go-webapp/decoder"
+const webappPath = "github.com/mbict/go-webapp"
+
+// defaultTag is decoded with the whole tag as value, default values may contain commas.
+const defaultTag = "default"
+
+type generator struct {
+	tags  []string
+	types []string
+	empty []string
+
+	pkg     string
+	structs map[string]*ast.StructType
+	named   map[string]bool
+	paths   map[*ast.StructType]map[string]string
+	imports map[string]bool
+}
+
+// scalar is the conversion of a builtin kind, parse is the call with the value s, convert the conversion of the result n.
+type scalar struct {
+	parse   string
+	convert string
+}
+
+var scalars = map[string]scalar{
+	"string":  {},
+	"int":     {parse: "strconv.Atoi(s)"},
+	"int8":    {parse: "strconv.ParseInt(s, 10, 8)", convert: "int8(n)"},
+	"int16":   {parse: "strconv.ParseInt(s, 10, 16)", convert: "int16(n)"},
+	"int32":   {parse: "strconv.ParseInt(s, 10, 32)", convert: "int32(n)"},
+	"rune":    {parse: "strconv.ParseInt(s, 10, 32)", convert: "rune(n)"},
+	"int64":   {parse: "strconv.ParseInt(s, 10, 64)"},
+	"uint":    {parse: "strconv.ParseUint(s, 10, strconv.IntSize)", convert: "uint(n)"},
+	"uint8":   {parse: "strconv.ParseUint(s, 10, 8)", convert: "uint8(n)"},
+	"byte":    {parse: "strconv.ParseUint(s, 10, 8)", convert: "byte(n)"},
+	"uint16":  {parse: "strconv.ParseUint(s, 10, 16)", convert: "uint16(n)"},
+	"uint32":  {parse: "strconv.ParseUint(s, 10, 32)", convert: "uint32(n)"},
+	"uint64":  {parse: "strconv.ParseUint(s, 10, 64)"},
+	"float32": {parse: "strconv.ParseFloat(s, 32)", convert: "float32(n)"},
+	"float64": {parse: "strconv.ParseFloat(s, 64)"},
+	"bool":    {parse: "strconv.ParseBool(s)"},
+}
+
+// generate parses the package in dir, test files are only included when the output is a test file.
+func (g *generator) generate(dir string, tests bool) ([]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") && !tests {
+			continue
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		//skip earlier generated files, they may not compile anymore
+		if bytes.Contains(src, []byte("// Code generated by webapp-gen. DO NOT EDIT.")) {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	return g.generateFiles(files)
+}
+
+// generateFiles generates the source for the types declared in files, the package is the one of the first type.
+func (g *generator) generateFiles(files []*ast.File) ([]byte, error) {
+	if len(g.types) == 0 && len(g.empty) == 0 {
+		return nil, errors.New("no types given")
+	}
+
+	g.load(files, append(g.types, g.empty...)[0])
+	if g.pkg == "" {
+		return nil, fmt.Errorf("type %s not found", append(g.types, g.empty...)[0])
+	}
+
+	var body, register bytes.Buffer
+	g.imports = map[string]bool{}
+
+	for _, name := range g.types {
+		st, ok := g.structs[name]
+		if !ok {
+			return nil, fmt.Errorf("request type %s not found or not a struct", name)
+		}
+
+		for _, tag := range g.tags {
+			var stmts bytes.Buffer
+			ok, err := g.bindFields(&stmts, tag, st, "v")
+			if err != nil {
+				fmt.Fprintf(&body, "// %s falls back to reflection for the %s tag, %s.\n\n", name, tag, err)
+				continue
+			}
+
+			if !ok {
+				continue
+			}
+
+			fn := "bind" + exported(name) + exported(tag)
+			fmt.Fprintf(&register, "%sRegisterBindFunc(%q, %s)\n", g.qualifier("decoder"), tag, fn)
+			fmt.Fprintf(&body, "func %s(g %sGetter, v *%s) error {\n%sreturn nil\n}\n\n", fn, g.qualifier("decoder"), name, stmts.String())
+		}
+	}
+
+	for _, name := range g.empty {
+		st, ok := g.structs[name]
+		if !ok {
+			return nil, fmt.Errorf("response type %s not found or not a struct", name)
+		}
+
+		//the response implements the empty interface, so it is always empty
+		isEmpty := g.embedsEmpty(st)
+
+		fmt.Fprintf(&register, "%sRegisterEmptyCheck(func(v %s) bool { return %t })\n", g.qualifier("webapp"), name, isEmpty)
+		if isEmpty {
+			fmt.Fprintf(&register, "%sRegisterEmptyCheck(func(v *%s) bool { return true })\n", g.qualifier("webapp"), name)
+		} else {
+			fmt.Fprintf(&register, "%sRegisterEmptyCheck(func(v *%s) bool { return v == nil })\n", g.qualifier("webapp"), name)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by webapp-gen. DO NOT EDIT.\n\npackage %s\n\n", g.pkg)
+
+	if len(g.imports) > 0 {
+		buf.WriteString("import (\n")
+		for _, path := range []string{webappPath, decoderPath, "strconv"} {
+			if g.imports[path] {
+				fmt.Fprintf(&buf, "%q\n", path)
+			}
+		}
+		buf.WriteString(")\n\n")
+	}
+
+	if register.Len() > 0 {
+		fmt.Fprintf(&buf, "func init() {\n%s}\n\n", register.String())
+	}
+	buf.Write(body.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+// load collects the type declarations of the package that declares the type name.
+func (g *generator) load(files []*ast.File, name string) {
+	g.structs = map[string]*ast.StructType{}
+	g.named = map[string]bool{}
+	g.paths = map[*ast.StructType]map[string]string{}
+
+	for _, f := range files {
+		if declares(f, name) {
+			g.pkg = f.Name.Name
+			break
+		}
+	}
+
+	for _, f := range files {
+		if f.Name.Name != g.pkg {
+			continue
+		}
+
+		paths := importPaths(f)
+
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				g.named[ts.Name.Name] = true
+				if st, ok := ts.Type.(*ast.StructType); ok && ts.TypeParams == nil {
+					g.structs[ts.Name.Name] = st
+					g.paths[st] = paths
+				}
+			}
+		}
+	}
+}
+
+// importPaths returns the import paths of the file by package name, the name is guessed from the last path element.
+func importPaths(f *ast.File) map[string]string {
+	paths := map[string]string{}
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		paths[name] = path
+	}
+	return paths
+}
+
+func declares(f *ast.File, name string) bool {
+	for _, decl := range f.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+			for _, spec := range gd.Specs {
+				if spec.(*ast.TypeSpec).Name.Name == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// qualifier returns the package qualifier, including the dot, of the decoder or webapp package and marks the import as used.
+func (g *generator) qualifier(pkg string) string {
+	path := decoderPath
+	if pkg == "webapp" {
+		path = webappPath
+	}
+
+	if g.pkg == pkg {
+		return ""
+	}
+
+	g.imports[path] = true
+	return pkg + "."
+}
+
+// bindFields writes the statements that bind the fields of st with the tag, expr is the expression of the struct value.
+// It reports if any field is bound, an error is returned for fields that need the reflection decoder.
+//
+//nolint:cyclop
+func (g *generator) bindFields(w *bytes.Buffer, tag string, st *ast.StructType, expr string) (bool, error) {
+	bound := false
+
+	for _, f := range st.Fields.List {
+		typ, ptr := f.Type, false
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ, ptr = star.X, true
+		}
+
+		names := f.Names
+		if len(names) == 0 {
+			if id, ok := typ.(*ast.Ident); ok {
+				names = []*ast.Ident{id}
+			} else if sel, ok := typ.(*ast.SelectorExpr); ok {
+				names = []*ast.Ident{sel.Sel}
+			}
+		}
+
+		var value string
+		var tagged bool
+		if f.Tag != nil {
+			raw, _ := strconv.Unquote(f.Tag.Value)
+			value, tagged = reflect.StructTag(raw).Lookup(tag)
+		}
+
+		for _, name := range names {
+			if !name.IsExported() {
+				continue // skip unexported fields
+			}
+
+			field := expr + "." + name.Name
+
+			if !tagged {
+				switch t := typ.(type) {
+				case *ast.Ident:
+					nested, ok := g.structs[t.Name]
+					if !ok {
+						continue
+					}
+
+					var stmts bytes.Buffer
+					ok, err := g.bindFields(&stmts, tag, nested, field)
+					if err != nil {
+						return false, err
+					}
+
+					if ok {
+						if ptr {
+							fmt.Fprintf(w, "if %s == nil {\n%s = new(%s)\n}\n", field, field, t.Name)
+						}
+						w.Write(stmts.Bytes())
+						bound = true
+					}
+				case *ast.SelectorExpr:
+					//types of the standard library do not bind nested fields
+					if x, ok := t.X.(*ast.Ident); ok && !strings.Contains(strings.Split(g.paths[st][x.Name], "/")[0], ".") {
+						continue
+					}
+					return false, fmt.Errorf("field %s of type %s is not supported", name.Name, typeName(t))
+				}
+				continue
+			}
+
+			key, options := value, ""
+			if tag != defaultTag {
+				key, options, _ = strings.Cut(value, ",")
+			}
+
+			required := false
+			for _, option := range strings.Split(options, ",") {
+				switch option {
+				case "":
+				case "required":
+					required = true
+				default:
+					return false, fmt.Errorf("option %s of field %s is not supported", option, name.Name)
+				}
+			}
+
+			if required {
+				fmt.Fprintf(w, "if g.Get(%q) == \"\" {\nreturn &%sMissingError{Source: %q, Key: %q}\n}\n", key, g.qualifier("decoder"), tag, key)
+			}
+
+			if err := g.bindField(w, field, typ, ptr, key); err != nil {
+				return false, fmt.Errorf("field %s %s", name.Name, err)
+			}
+			bound = true
+		}
+	}
+
+	return bound, nil
+}
+
+// bindField writes the statement that binds the value of key into the field.
+func (g *generator) bindField(w *bytes.Buffer, field string, typ ast.Expr, ptr bool, key string) error {
+	if arr, ok := typ.(*ast.ArrayType); ok {
+		if id, ok := arr.Elt.(*ast.Ident); ok && id.Name == "string" && arr.Len == nil && !ptr {
+			fmt.Fprintf(w, "if s := g.Values(%q); s != nil {\n%s = s\n}\n", key, field)
+			return nil
+		}
+		return fmt.Errorf("of type %s is not supported", typeName(typ))
+	}
+
+	id, ok := typ.(*ast.Ident)
+	if !ok {
+		return fmt.Errorf("of type %s is not supported", typeName(typ))
+	}
+
+	conv, ok := scalars[id.Name]
+	if !ok || g.named[id.Name] {
+		return fmt.Errorf("of type %s is not supported", id.Name)
+	}
+
+	fmt.Fprintf(w, "if s := g.Get(%q); s != \"\" {\n", key)
+
+	val := "s"
+	if conv.parse != "" {
+		g.imports["strconv"] = true
+		fmt.Fprintf(w, "n, err := %s\nif err != nil {\nreturn err\n}\n", conv.parse)
+
+		val = "n"
+		if conv.convert != "" {
+			val = conv.convert
+		}
+	}
+
+	if ptr {
+		fmt.Fprintf(w, "if %s == nil {\n%s = new(%s)\n}\n*%s = %s\n", field, field, id.Name, field, val)
+	} else {
+		fmt.Fprintf(w, "%s = %s\n", field, val)
+	}
+
+	w.WriteString("}\n")
+	return nil
+}
+
+// embedsEmpty reports if the struct embeds webapp.Empty, which makes the response always empty.
+func (g *generator) embedsEmpty(st *ast.StructType) bool {
+	for _, f := range st.Fields.List {
+		if len(f.Names) > 0 {
+			continue
+		}
+
+		typ, ptr := f.Type, false
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ, ptr = star.X, true
+		}
+
+		switch t := typ.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := t.X.(*ast.Ident); ok && x.Name == "webapp" && t.Sel.Name == "Empty" {
+				return true
+			}
+		case *ast.Ident:
+			if g.pkg == "webapp" && t.Name == "Empty" {
+				return true
+			}
+
+			if nested, ok := g.structs[t.Name]; ok && !ptr && g.embedsEmpty(nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeName(typ ast.Expr) string {
+	var buf bytes.Buffer
+	_ = format.Node(&buf, token.NewFileSet(), typ)
+	return buf.String()
+}
+
+func exported(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+const source = `package api
+
+import (
+	"github.com/mbict/go-webapp"
+	"time"
+)
+
+type paging struct {
+	Limit int ` + "`query:\"limit\" default:\"10\"`" + `
+}
+
+type ListRequest struct {
+	Tenant string ` + "`path:\"tenant,required\"`" + `
+	Tags   []string ` + "`query:\"tags\"`" + `
+	Level  *uint8 ` + "`header:\"X-Level\"`" + `
+	Since  time.Time ` + "`cookie:\"since\"`" + `
+	Filter map[string]string ` + "`header:\"filter\"`" + `
+	paging
+	Page *paging
+}
+
+type ListResponse struct {
+	Items []string
+}
+
+type DeletedResponse struct {
+	webapp.Empty
+}
+`
+
+func generateSource(t *testing.T, g *generator) string {
+	f, err := parser.ParseFile(token.NewFileSet(), "api.go", source, 0)
+	assert.NoError(t, err)
+
+	src, err := g.generateFiles([]*ast.File{f})
+	assert.NoError(t, err)
+	return string(src)
+}
+
+func TestGenerateBindFuncs(t *testing.T) {
+	src := generateSource(t, &generator{
+		tags:  []string{"path", "query", "header", "cookie", "default"},
+		types: []string{"ListRequest"},
+	})
+
+	assert.Contains(t, src, "package api")
+	assert.Contains(t, src, `decoder.RegisterBindFunc("path", bindListRequestPath)`)
+	assert.Contains(t, src, `return &decoder.MissingError{Source: "path", Key: "tenant"}`)
+	assert.Contains(t, src, "func bindListRequestQuery(g decoder.Getter, v *ListRequest) error {")
+	assert.Contains(t, src, "v.Tags = s")
+	assert.Contains(t, src, "v.Page = new(paging)")
+	assert.Contains(t, src, `g.Get("10")`, "default values use the whole tag")
+
+	//unexported embedded structs are skipped
+	assert.NotContains(t, src, "v.paging")
+
+	//unsupported fields fall back to reflection for the tag
+	assert.Contains(t, src, "// ListRequest falls back to reflection for the header tag")
+	assert.Contains(t, src, "// ListRequest falls back to reflection for the cookie tag")
+	assert.NotContains(t, src, "bindListRequestHeader")
+}
+
+func TestGenerateEmptyChecks(t *testing.T) {
+	src := generateSource(t, &generator{
+		empty: []string{"ListResponse", "DeletedResponse"},
+	})
+
+	assert.Contains(t, src, "webapp.RegisterEmptyCheck(func(v *ListResponse) bool { return v == nil })")
+	assert.Contains(t, src, "webapp.RegisterEmptyCheck(func(v ListResponse) bool { return false })")
+	assert.Contains(t, src, "webapp.RegisterEmptyCheck(func(v DeletedResponse) bool { return true })")
+	assert.NotContains(t, src, "decoder")
+}
+
+func TestGenerateUnknownType(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "api.go", source, 0)
+	assert.NoError(t, err)
+
+	_, err = (&generator{types: []string{"Missing"}}).generateFiles([]*ast.File{f})
+	assert.EqualError(t, err, "type Missing not found")
+}
@@ -0,0 +1,54 @@
+// Command webapp-gen generates specialised bind funcs and empty checks for request and response types, so the
+// handlers do not need reflection to decode the arguments of a request.
+//
+//	//go:generate go run github.com/mbict/go-webapp/cmd/webapp-gen -type GreetRequest -empty GreetResponse
+//
+// The generated funcs are registered in an init func and picked up by H automatically. Fields the generator does
+// not support, like maps, Optional values, prefixed structs or fields with a layout or unit, make the type fall back
+// to reflection for that tag.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	types := flag.String("type", "", "comma separated list of request types to generate bind funcs for")
+	empty := flag.String("empty", "", "comma separated list of response types to generate empty checks for")
+	tags := flag.String("tags", "path,query,header,cookie,default", "comma separated list of tags to generate bind funcs for")
+	output := flag.String("output", "webapp_gen.go", "output file name")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	g := &generator{
+		tags:  split(*tags),
+		types: split(*types),
+		empty: split(*empty),
+	}
+
+	src, err := g.generate(dir, strings.HasSuffix(*output, "_test.go"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "webapp-gen:", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "webapp-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,23 @@
+package decoder
+
+import (
+	"net/http"
+)
+
+type principalKey struct{}
+
+// PrincipalKey is the request context key under which the authenticated principal is stored.
+// The principal must implement the Getter interface to be bindable with the auth tag.
+var PrincipalKey = principalKey{}
+
+func NewAuthDecoder(v any, tag string) (Decode, error) {
+	return NewGetterDecoder(v, tag, AuthValues)
+}
+
+// AuthValues returns the authenticated principal, or nil for anonymous requests.
+func AuthValues(req *http.Request) Getter {
+	if principal, ok := req.Context().Value(PrincipalKey).(Getter); ok {
+		return principal
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Code generated by webapp-gen. DO NOT EDIT.
+
+package decoder_test
+
+import (
+	"github.com/mbict/go-webapp/decoder"
+	"strconv"
+)
+
+func init() {
+	decoder.RegisterBindFunc("schema", bindBenchRequestSchema)
+}
+
+func bindBenchRequestSchema(g decoder.Getter, v *benchRequest) error {
+	if s := g.Get("string"); s != "" {
+		v.String = s
+	}
+	if s := g.Get("string"); s != "" {
+		if v.StringPtr == nil {
+			v.StringPtr = new(string)
+		}
+		*v.StringPtr = s
+	}
+	if s := g.Get("int"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		v.Int = n
+	}
+	if s := g.Get("int8"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 8)
+		if err != nil {
+			return err
+		}
+		v.Int8 = int8(n)
+	}
+	if s := g.Get("int16"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 16)
+		if err != nil {
+			return err
+		}
+		v.Int16 = int16(n)
+	}
+	if s := g.Get("int32"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return err
+		}
+		v.Int32 = int32(n)
+	}
+	if s := g.Get("int64"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.Int64 = n
+	}
+	if s := g.Get("uint"); s != "" {
+		n, err := strconv.ParseUint(s, 10, strconv.IntSize)
+		if err != nil {
+			return err
+		}
+		v.Uint = uint(n)
+	}
+	if s := g.Get("uint8"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			return err
+		}
+		v.Uint8 = uint8(n)
+	}
+	if s := g.Get("uint16"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 16)
+		if err != nil {
+			return err
+		}
+		v.Uint16 = uint16(n)
+	}
+	if s := g.Get("uint32"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return err
+		}
+		v.Uint32 = uint32(n)
+	}
+	if s := g.Get("uint64"); s != "" {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.Uint64 = n
+	}
+	if s := g.Get("float32"); s != "" {
+		n, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return err
+		}
+		v.Float32 = float32(n)
+	}
+	if s := g.Get("float64"); s != "" {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.Float64 = n
+	}
+	if s := g.Get("bool"); s != "" {
+		n, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.Bool = n
+	}
+	if s := g.Values("strings"); s != nil {
+		v.Strings = s
+	}
+	if s := g.Get("string"); s != "" {
+		v.Nested.String = s
+	}
+	if v.NestedPtr == nil {
+		v.NestedPtr = new(benchChild)
+	}
+	if s := g.Get("string"); s != "" {
+		v.NestedPtr.String = s
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package decoder
 import (
 	"encoding"
 	"errors"
+	"fmt"
 	"github.com/mbict/go-webapp/internal"
 	"reflect"
 	"strconv"
@@ -11,14 +12,30 @@ import (
 )
 
 var ErrUnsupportedType = errors.New("decoder: unsupported type")
+var ErrTooManyValues = errors.New("decoder: too many values for array")
+
+var unmarshalerType = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
 
 type decoder func(reflect.Value, Getter) error
 
-//nolint:cyclop
+// compiler holds the settings a struct decoder is compiled with.
+type compiler struct {
+	tagKey string
+
+	// rawTag uses the whole tag as key without options, default values may contain commas.
+	rawTag bool
+}
+
 func compile(typ reflect.Type, tagKey string, isPtr bool) (decoder, error) {
-	decoders := []decoder{}
+	return (&compiler{tagKey: tagKey}).compile(typ, isPtr, nil)
+}
 
-	unmarshalerType := reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
+// compile compiles the decoder of a struct nested under the path of prefixes, nil for the root struct.
+//
+//nolint:cyclop
+func (c *compiler) compile(typ reflect.Type, isPtr bool, path []string) (decoder, error) {
+	tagKey := c.tagKey
+	decoders := []decoder{}
 
 	for i := 0; i < typ.NumField(); i++ {
 		f := typ.Field(i)
@@ -33,70 +50,26 @@ func compile(typ reflect.Type, tagKey string, isPtr bool) (decoder, error) {
 			continue
 		}
 
-		tag, options := parseTag(tag)
-
-		if reflect.PointerTo(t).Implements(unmarshalerType) {
-			decoders = append(decoders, decodeTextUnmarshaler(get(ptr, i, t), tag))
-			continue
+		var options string
+		if !c.rawTag {
+			tag, options = parseTag(tag)
 		}
 
-		switch k {
-		case reflect.Struct:
-			dec, err := compile(t, tagKey, ptr)
-			if err != nil {
-				return nil, err
+		if hasOption(options, "required") {
+			//nested structs have no value of their own, their fields can be required
+			if k == reflect.Struct && !bindsValue(t) {
+				return nil, fmt.Errorf("%w: required on %s", ErrUnsupportedOption, t)
 			}
+			decoders = append(decoders, require(tagKey, tag, path, k == reflect.Map))
+		}
 
-			index := i
-
-			decoders = append(decoders, func(v reflect.Value, m Getter) error {
-				return dec(v.Field(index), m)
-			})
-		case reflect.String:
-			decoders = append(decoders, decodeString(set[string](ptr, i, t), tag))
-		case reflect.Int:
-			decoders = append(decoders, decodeInt(set[int](ptr, i, t), tag))
-		case reflect.Int8:
-			decoders = append(decoders, decodeInt8(set[int8](ptr, i, t), tag))
-		case reflect.Int16:
-			decoders = append(decoders, decodeInt16(set[int16](ptr, i, t), tag))
-		case reflect.Int32:
-			decoders = append(decoders, decodeInt32(set[int32](ptr, i, t), tag))
-		case reflect.Int64:
-			decoders = append(decoders, decodeInt64(set[int64](ptr, i, t), tag))
-		case reflect.Uint:
-			decoders = append(decoders, decodeUint(set[uint](ptr, i, t), tag))
-		case reflect.Uint8:
-			decoders = append(decoders, decodeUint8(set[uint8](ptr, i, t), tag))
-		case reflect.Uint16:
-			decoders = append(decoders, decodeUint16(set[uint16](ptr, i, t), tag))
-		case reflect.Uint32:
-			decoders = append(decoders, decodeUint32(set[uint32](ptr, i, t), tag))
-		case reflect.Uint64:
-			decoders = append(decoders, decodeUint64(set[uint64](ptr, i, t), tag))
-		case reflect.Float32:
-			decoders = append(decoders, decodeFloat32(set[float32](ptr, i, t), tag))
-		case reflect.Float64:
-			decoders = append(decoders, decodeFloat64(set[float64](ptr, i, t), tag))
-		case reflect.Bool:
-			decoders = append(decoders, decodeBool(set[bool](ptr, i, t), tag))
-		case reflect.Slice:
-
-			//slice with a text unmarshaller, time and uuid for example
-			if reflect.PointerTo(t.Elem()).Implements(unmarshalerType) {
-				decoders = append(decoders, decodeTextUnmarshalerSlice(i, get(ptr, i, t), tag, getDelimiterFromOptions(options)))
-				continue
-			}
+		dec, err := c.compileField(t, k, ptr, i, tag, ok, options, path)
+		if err != nil {
+			return nil, err
+		}
 
-			_, sk, _ := typeKind(t.Elem())
-			switch sk {
-			case reflect.String:
-				decoders = append(decoders, decodeStrings(set[[]string](ptr, i, t), tag, getDelimiterFromOptions(options)))
-			case reflect.Uint8:
-				decoders = append(decoders, decodeBytes(set[[]byte](ptr, i, t), tag))
-			}
-		default:
-			return nil, ErrUnsupportedType
+		if dec != nil {
+			decoders = append(decoders, dec)
 		}
 	}
 
@@ -123,6 +96,101 @@ func compile(typ reflect.Type, tagKey string, isPtr bool) (decoder, error) {
 	}, nil
 }
 
+// compileField compiles the decoder of field i of type t, tagged tells if the field has the tag of the compiler.
+//
+//nolint:cyclop
+func (c *compiler) compileField(t reflect.Type, k reflect.Kind, ptr bool, i int, tag string, tagged bool, options string, path []string) (decoder, error) {
+	if isOptional(t) {
+		if !tagged {
+			return nil, nil
+		}
+		return c.compileOptional(t, ptr, i, tag, options, path)
+	}
+
+	conv, err := converterFor(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if conv != nil {
+		return conv.single(ptr, i, t, tag), nil
+	}
+
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return decodeTextUnmarshaler(get(ptr, i, t), tag), nil
+	}
+
+	switch k {
+	case reflect.Struct:
+		//a tagged struct field binds its fields prefixed, filter.name or filter[name]
+		childPath := path
+		if tagged && tag != "" {
+			childPath = append(path[:len(path):len(path)], tag)
+		}
+
+		dec, err := c.compile(t, ptr, childPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(childPath) == len(path) {
+			return func(v reflect.Value, m Getter) error {
+				return dec(v.Field(i), m)
+			}, nil
+		}
+
+		dot, bracket := prefixes(childPath)
+		return func(v reflect.Value, m Getter) error {
+			return dec(v.Field(i), newPrefixGetter(m, dot, bracket))
+		}, nil
+	case reflect.Map:
+		if ptr || t.Key().Kind() != reflect.String {
+			return nil, ErrUnsupportedType
+		}
+
+		switch {
+		case t.Elem().Kind() == reflect.String:
+			return decodeMap(i, tag, Getter.Get), nil
+		case t.Elem().Kind() == reflect.Slice && t.Elem().Elem().Kind() == reflect.String:
+			return decodeMap(i, tag, Getter.Values), nil
+		default:
+			return nil, ErrUnsupportedType
+		}
+	case reflect.String:
+		return decodeString(set[string](ptr, i, t), tag), nil
+	case reflect.Int:
+		return decodeInt(set[int](ptr, i, t), tag), nil
+	case reflect.Int8:
+		return decodeInt8(set[int8](ptr, i, t), tag), nil
+	case reflect.Int16:
+		return decodeInt16(set[int16](ptr, i, t), tag), nil
+	case reflect.Int32:
+		return decodeInt32(set[int32](ptr, i, t), tag), nil
+	case reflect.Int64:
+		return decodeInt64(set[int64](ptr, i, t), tag), nil
+	case reflect.Uint:
+		return decodeUint(set[uint](ptr, i, t), tag), nil
+	case reflect.Uint8:
+		return decodeUint8(set[uint8](ptr, i, t), tag), nil
+	case reflect.Uint16:
+		return decodeUint16(set[uint16](ptr, i, t), tag), nil
+	case reflect.Uint32:
+		return decodeUint32(set[uint32](ptr, i, t), tag), nil
+	case reflect.Uint64:
+		return decodeUint64(set[uint64](ptr, i, t), tag), nil
+	case reflect.Float32:
+		return decodeFloat32(set[float32](ptr, i, t), tag), nil
+	case reflect.Float64:
+		return decodeFloat64(set[float64](ptr, i, t), tag), nil
+	case reflect.Bool:
+		return decodeBool(set[bool](ptr, i, t), tag), nil
+	case reflect.Slice, reflect.Array:
+		return compileSlice(t, ptr, i, tag, options)
+	}
+
+	return nil, ErrUnsupportedType
+}
+
 func typeKind(t reflect.Type) (reflect.Type, reflect.Kind, bool) {
 	var isPtr bool
 
@@ -180,6 +248,22 @@ func decodeTextUnmarshaler(get func(reflect.Value) reflect.Value, k string) deco
 	}
 }
 
+// decodeValue decodes a single value with the conversion function.
+func decodeValue[T any](set func(reflect.Value, T), k string, convert func(string) (T, error)) decoder {
+	return func(v reflect.Value, g Getter) error {
+		if s := g.Get(k); s != "" {
+			n, err := convert(s)
+			if err != nil {
+				return err
+			}
+
+			set(v, n)
+		}
+
+		return nil
+	}
+}
+
 func decodeString(set func(reflect.Value, string), k string) decoder {
 	return func(v reflect.Value, g Getter) error {
 		if s := g.Get(k); s != "" {
@@ -397,44 +481,34 @@ func decodeBytes(set func(reflect.Value, []byte), k string) decoder {
 func decodeStrings(set func(reflect.Value, []string), k string, delimiter string) decoder {
 	return func(v reflect.Value, g Getter) error {
 		if s := g.Values(k); s != nil {
-
-			if delimiter != "" {
-				var res []string
-				for _, value := range s {
-					res = append(res, strings.Split(value, delimiter)...)
-				}
-				s = res
-			}
-			set(v, s)
+			set(v, splitValues(s, delimiter))
 		}
 
 		return nil
 	}
 }
 
-func decodeTextUnmarshalerSlice(i int, get func(reflect.Value) reflect.Value, k string, delimiter string) decoder {
+// decodeMap collects the keys name.key and name[key] into the map.
+func decodeMap[T string | []string](i int, name string, value func(Getter, string) T) decoder {
 	return func(v reflect.Value, g Getter) error {
-		if s := g.Values(k); s != nil {
+		kg, ok := g.(KeysGetter)
+		if !ok {
+			return nil
+		}
 
-			if delimiter != "" {
-				var res []string
-				for _, value := range s {
-					res = append(res, strings.Split(value, delimiter)...)
-				}
-				s = res
+		for _, key := range kg.Keys() {
+			k, ok := mapKey(name, key)
+			if !ok {
+				continue
 			}
 
-			var values []reflect.Value
-			for _, val := range s {
-				marshVal := reflect.New(get(v).Type().Elem().Elem())
-				if err := marshVal.Interface().(encoding.TextUnmarshaler).UnmarshalText(internal.Atob(val)); err != nil {
-					return err
-				}
-
-				values = append(values, reflect.Indirect(marshVal))
+			m := (*map[string]T)(unsafe.Pointer(v.Field(i).UnsafeAddr()))
+			if *m == nil {
+				*m = map[string]T{}
 			}
-			v.Field(i).Set(reflect.Append(v.Field(i), values...))
+			(*m)[k] = value(g, key)
 		}
+
 		return nil
 	}
 }
@@ -444,6 +518,66 @@ func parseTag(tag string) (string, string) {
 	return tag, opt
 }
 
+// require returns a decoder that fails when the key has no value, a map requires at least one key.
+func require(source string, k string, path []string, isMap bool) decoder {
+	err := &MissingError{Source: source, Key: k}
+	if len(path) > 0 {
+		err.Key = strings.Join(path, ".") + "." + k
+	}
+
+	return func(v reflect.Value, g Getter) error {
+		if isMap {
+			if kg, ok := g.(KeysGetter); ok {
+				for _, key := range kg.Keys() {
+					if _, ok := mapKey(k, key); ok {
+						return nil
+					}
+				}
+			}
+			return err
+		}
+
+		if g.Get(k) == "" {
+			return err
+		}
+		return nil
+	}
+}
+
+// bindsValue reports if a struct type is bound from a single value, like time.Time and Optional, instead of
+// binding its fields.
+func bindsValue(t reflect.Type) bool {
+	if isOptional(t) || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return true
+	}
+	_, ok := converters.Load(t)
+	return ok
+}
+
+// hasOption reports if the flag option is set.
+func hasOption(o string, name string) bool {
+	for o != "" {
+		var option string
+		option, o, _ = strings.Cut(o, ",")
+		if option == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getOption returns the value of the option name=value.
+func getOption(o string, name string) (string, bool) {
+	for o != "" {
+		var option string
+		option, o, _ = strings.Cut(o, ",")
+		if value, ok := strings.CutPrefix(option, name+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
 func getDelimiterFromOptions(o string) string {
 
 	if len(o) == 0 {
@@ -464,8 +598,8 @@ func getDelimiterFromOptions(o string) string {
 			return " "
 		case option == "tab-delimited":
 			return "\t"
-		case strings.HasPrefix(option, "delimiter=") == true:
-			_, delimiter, _ := strings.Cut(option, "=")
+		case strings.HasPrefix(option, "delimiter=") || strings.HasPrefix(option, "delimiter:"):
+			delimiter := option[len("delimiter="):]
 			switch delimiter {
 			case "space":
 				return " "
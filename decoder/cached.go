@@ -7,26 +7,41 @@ import (
 type Getter interface {
 	Get(string) string
 	Values(string) []string
+
+	// Has reports if the key is present, also when its value is empty.
+	Has(string) bool
 }
 
 type CachedDecoder struct {
-	dec decoder
+	dec  decoder
+	bind BindFunc
 }
 
 func NewCachedDecoder(v interface{}, tag string) (*CachedDecoder, error) {
+	return newCachedDecoder(v, &compiler{tagKey: tag})
+}
+
+func newCachedDecoder(v interface{}, c *compiler) (*CachedDecoder, error) {
 	t, k, ptr := typeKind(reflect.TypeOf(v))
 	if k != reflect.Struct {
 		return nil, ErrUnsupportedType
 	}
 
-	dec, err := compile(t, tag, ptr)
+	if bind := lookupBindFunc(t, c.tagKey); bind != nil {
+		return &CachedDecoder{bind: bind}, nil
+	}
+
+	dec, err := c.compile(t, ptr, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &CachedDecoder{dec}, nil
+	return &CachedDecoder{dec: dec}, nil
 }
 
 func (d *CachedDecoder) Decode(data Getter, v interface{}) error {
+	if d.bind != nil {
+		return d.bind(data, v)
+	}
 	return d.dec(reflect.ValueOf(v).Elem(), data)
 }
@@ -0,0 +1,123 @@
+package decoder
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type trustedProxiesKey struct{}
+
+// TrustedProxiesKey is the request context key under which the trusted proxies are stored.
+var TrustedProxiesKey = trustedProxiesKey{}
+
+// TrustedProxies are the networks of the proxies that are trusted to forward the client address.
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses the networks in CIDR notation, a single address is a network of its own.
+func ParseTrustedProxies(proxies ...string) (TrustedProxies, error) {
+	res := make(TrustedProxies, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, prefix.Masked())
+	}
+	return res, nil
+}
+
+// Contains reports if the address is one of a trusted proxy.
+func (t TrustedProxies) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func trustedProxiesFromContext(ctx context.Context) TrustedProxies {
+	proxies, _ := ctx.Value(TrustedProxiesKey).(TrustedProxies)
+	return proxies
+}
+
+// ClientIP returns the address of the client. The forwarded addresses of the Forwarded or X-Forwarded-For header
+// are only used when the request comes from a trusted proxy, the last address that is not a trusted proxy is the client.
+// An empty string is returned when that address is obfuscated or unknown.
+func ClientIP(req *http.Request) string {
+	remote, err := parseAddr(req.RemoteAddr)
+	if err != nil {
+		return hostOf(req.RemoteAddr)
+	}
+
+	proxies := trustedProxiesFromContext(req.Context())
+	if !proxies.Contains(remote) {
+		return remote.String()
+	}
+
+	forwarded := forwardedFor(req.Header)
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := parseAddr(forwarded[i])
+		if err != nil {
+			return ""
+		}
+
+		if !proxies.Contains(addr) || i == 0 {
+			return addr.String()
+		}
+	}
+	return remote.String()
+}
+
+// forwardedFor returns the forwarded addresses, from the client to the last proxy. The Forwarded header takes precedence
+// over X-Forwarded-For.
+func forwardedFor(h http.Header) []string {
+	var res []string
+	for _, value := range h.Values("Forwarded") {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(k, "for") {
+					res = append(res, strings.Trim(v, `"`))
+				}
+			}
+		}
+	}
+
+	if len(res) > 0 {
+		return res
+	}
+
+	for _, value := range h.Values("X-Forwarded-For") {
+		for _, addr := range strings.Split(value, ",") {
+			res = append(res, strings.TrimSpace(addr))
+		}
+	}
+	return res
+}
+
+// parseAddr parses an address with an optional port, IPv6 addresses with a port are enclosed in brackets.
+func parseAddr(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.Trim(hostOf(s), "[]"))
+	return addr.Unmap(), err
+}
+
+func hostOf(s string) string {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		return host
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package decoder
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ContextKey is a request context key that can be bound with the ctx tag, `ctx:"tenant"` binds the value
+// stored under ContextKey("tenant").
+type ContextKey string
+
+var contextKeys sync.Map
+
+// RegisterContextKey makes the value stored under the context key of another package bindable by name,
+// e.g. RegisterContextKey("request-id", RequestIDKey) binds the request id with `ctx:"request-id"`.
+func RegisterContextKey(name string, key any) {
+	contextKeys.Store(name, key)
+}
+
+func NewContextDecoder(v any, tag string) (Decode, error) {
+	return NewGetterDecoder(v, tag, ContextValues)
+}
+
+// ContextValues returns the values stored in the request context by middleware.
+func ContextValues(req *http.Request) Getter {
+	return &ContextGetter{Context: req.Context()}
+}
+
+// ContextGetter formats the typed values of the context, so they are converted just like query values.
+// Values implementing encoding.TextMarshaler or fmt.Stringer are formatted with them, a []string provides multiple values.
+type ContextGetter struct {
+	context.Context
+}
+
+func (c *ContextGetter) value(key string) any {
+	if k, ok := contextKeys.Load(key); ok {
+		return c.Value(k)
+	}
+	return c.Value(ContextKey(key))
+}
+
+func (c *ContextGetter) Get(key string) string {
+	return formatValue(c.value(key))
+}
+
+func (c *ContextGetter) Values(key string) []string {
+	switch v := c.value(key).(type) {
+	case nil:
+		return nil
+	case []string:
+		return v
+	default:
+		return []string{formatValue(v)}
+	}
+}
+
+func (c *ContextGetter) Has(key string) bool {
+	return c.value(key) != nil
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	case time.Duration:
+		//durations decode as nanoseconds
+		return strconv.FormatInt(int64(v), 10)
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	//dereference pointers to plain values, like *int
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		return formatValue(rv.Elem().Interface())
+	}
+	return fmt.Sprint(v)
+}
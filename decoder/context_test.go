@@ -0,0 +1,66 @@
+package decoder
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type tenantKey struct{}
+
+type contextTest struct {
+	Tenant    string        `ctx:"tenant"`
+	Locale    *string       `ctx:"locale"`
+	Limit     int           `ctx:"limit"`
+	LimitPtr  *int          `ctx:"limit-ptr"`
+	Timeout   time.Duration `ctx:"timeout"`
+	Since     time.Time     `ctx:"since"`
+	Roles     []string      `ctx:"roles"`
+	RequestID string        `ctx:"request-id"`
+	Missing   string        `ctx:"missing"`
+}
+
+func TestContextDecoder(t *testing.T) {
+	RegisterContextKey("tenant", tenantKey{})
+	RegisterContextKey("request-id", RequestIDKey)
+
+	dec, err := NewContextDecoder(contextTest{}, "ctx")
+	assert.NoError(t, err)
+
+	since := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	limit := 20
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	ctx = context.WithValue(ctx, ContextKey("locale"), "nl")
+	ctx = context.WithValue(ctx, ContextKey("limit"), 10)
+	ctx = context.WithValue(ctx, ContextKey("limit-ptr"), &limit)
+	ctx = context.WithValue(ctx, ContextKey("timeout"), 90*time.Second)
+	ctx = context.WithValue(ctx, ContextKey("since"), since)
+	ctx = context.WithValue(ctx, ContextKey("roles"), []string{"admin", "user"})
+	ctx = context.WithValue(ctx, RequestIDKey, "abc-123")
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/", nil)
+
+	out := &contextTest{}
+	assert.NoError(t, dec(req, out))
+
+	assert.Equal(t, "acme", out.Tenant)
+	assert.Equal(t, "nl", *out.Locale)
+	assert.Equal(t, 10, out.Limit)
+	assert.Equal(t, 20, *out.LimitPtr)
+	assert.Equal(t, 90*time.Second, out.Timeout)
+	assert.True(t, since.Equal(out.Since))
+	assert.Equal(t, []string{"admin", "user"}, out.Roles)
+	assert.Equal(t, "abc-123", out.RequestID)
+	assert.Equal(t, "", out.Missing)
+}
+
+func TestContextDecoderConversionError(t *testing.T) {
+	dec, err := NewContextDecoder(contextTest{}, "ctx")
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequestWithContext(context.WithValue(context.Background(), ContextKey("limit"), "ten"), "GET", "/", nil)
+	assert.Error(t, dec(req, &contextTest{}))
+}
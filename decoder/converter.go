@@ -0,0 +1,135 @@
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownUnit       = errors.New("decoder: unknown unit")
+	ErrUnsupportedOption = errors.New("decoder: option not supported by the type")
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+var converters sync.Map
+
+// converter builds the decoders of a type with a conversion function.
+type converter struct {
+	single func(ptr bool, i int, t reflect.Type, k string) decoder
+	list   func(t reflect.Type, ptr bool, i int, k string, delimiter string, elemPtr bool) (decoder, error)
+}
+
+func converterOf[T any](convert func(string) (T, error)) *converter {
+	return &converter{
+		single: func(ptr bool, i int, t reflect.Type, k string) decoder {
+			return decodeValue(set[T](ptr, i, t), k, convert)
+		},
+		list: func(t reflect.Type, ptr bool, i int, k string, delimiter string, elemPtr bool) (decoder, error) {
+			return sliceDecoder(t, ptr, i, k, delimiter, elemPtr, convert)
+		},
+	}
+}
+
+// RegisterConverter registers the conversion of a value into T, it is used for T, *T and slices of them
+// and takes precedence over encoding.TextUnmarshaler. Decoders are compiled once, so register converters
+// before the handlers are created.
+//
+//	decoder.RegisterConverter(func(s string) (decimal.Decimal, error) {
+//		return decimal.NewFromString(s)
+//	})
+func RegisterConverter[T any](convert func(string) (T, error)) {
+	converters.Store(reflect.TypeOf((*T)(nil)).Elem(), converterOf(convert))
+}
+
+// converterFor returns the converter of the type, the field options layout and unit take precedence over the
+// registered converters. Nil is returned when the type has no converter.
+//
+// A time.Duration without unit is a number of nanoseconds. The unit option also accepts durations like 1m30s,
+// register time.ParseDuration as converter to accept those for every duration.
+func converterFor(t reflect.Type, options string) (*converter, error) {
+	//the options of slices apply to their elements
+	if k := t.Kind(); k != reflect.Slice && k != reflect.Array {
+		if _, ok := getOption(options, "layout"); ok && t != timeType {
+			return nil, fmt.Errorf("%w: layout on %s", ErrUnsupportedOption, t)
+		}
+		if _, ok := getOption(options, "unit"); ok && t != durationType {
+			return nil, fmt.Errorf("%w: unit on %s", ErrUnsupportedOption, t)
+		}
+	}
+
+	switch t {
+	case timeType:
+		if layout, ok := getOption(options, "layout"); ok {
+			if named, ok := layouts[layout]; ok {
+				layout = named
+			}
+			return converterOf(func(s string) (time.Time, error) {
+				return time.Parse(layout, s)
+			}), nil
+		}
+	case durationType:
+		if unit, ok := getOption(options, "unit"); ok {
+			d, ok := units[unit]
+			if !ok {
+				return nil, ErrUnknownUnit
+			}
+			return converterOf(parseDuration(d)), nil
+		}
+	}
+
+	if c, ok := converters.Load(t); ok {
+		return c.(*converter), nil
+	}
+	return nil, nil
+}
+
+// layouts are the time layouts that can be referred to by name, `query:"since,layout=DateOnly"`.
+var layouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+var units = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// parseDuration parses a duration string like 1m30s, plain numbers are in the unit.
+func parseDuration(unit time.Duration) func(string) (time.Duration, error) {
+	return func(s string) (time.Duration, error) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Duration(n) * unit, nil
+		}
+
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return time.Duration(f * float64(unit)), nil
+		}
+
+		return time.ParseDuration(s)
+	}
+}
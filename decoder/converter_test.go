@@ -0,0 +1,91 @@
+package decoder
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// money is a third party like type without a text unmarshaler
+type money struct {
+	Currency string
+	Cents    int
+}
+
+func init() {
+	RegisterConverter(func(s string) (money, error) {
+		currency, amount, ok := strings.Cut(s, ":")
+		if !ok {
+			return money{}, errors.New("invalid money")
+		}
+		n, err := parseInt[int64](64)(amount)
+		return money{Currency: currency, Cents: int(n)}, err
+	})
+}
+
+type converterTest struct {
+	Price    money         `query:"price"`
+	PricePtr *money        `query:"price"`
+	Prices   []money       `query:"prices,comma-delimited"`
+	Since    time.Time     `query:"since,layout=2006-01-02"`
+	Until    *time.Time    `query:"until,layout=DateOnly"`
+	Days     []time.Time   `query:"days,layout=DateOnly,comma-delimited"`
+	Timeout  time.Duration `query:"timeout,unit=ms"`
+	Interval time.Duration `query:"interval,unit=s"`
+	Nanos    time.Duration `query:"nanos"`
+}
+
+func TestConverters(t *testing.T) {
+	dec, err := NewQueryDecoder(converterTest{}, "query")
+
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/foo?price=EUR:150&prices=EUR:1,USD:2&since=2022-05-01&until=2022-06-01&days=2022-01-01,2022-01-02&timeout=250&interval=1m30s&nanos=5", nil)
+
+	assert.NoError(t, err)
+
+	out := &converterTest{}
+	err = dec(req, out)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, money{"EUR", 150}, out.Price)
+	assert.Equal(t, &money{"EUR", 150}, out.PricePtr)
+	assert.Equal(t, []money{{"EUR", 1}, {"USD", 2}}, out.Prices)
+	assert.Equal(t, time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC), out.Since)
+	assert.Equal(t, time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), *out.Until)
+	assert.Equal(t, []time.Time{time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)}, out.Days)
+	assert.Equal(t, 250*time.Millisecond, out.Timeout)
+	assert.Equal(t, 90*time.Second, out.Interval)
+	assert.Equal(t, 5*time.Nanosecond, out.Nanos)
+
+	//durations without unit are plain numbers
+	req, _ = http.NewRequest("GET", "/foo?nanos=1m", nil)
+	assert.Error(t, dec(req, &converterTest{}))
+
+	req, _ = http.NewRequest("GET", "/foo?price=150", nil)
+	assert.EqualError(t, dec(req, &converterTest{}), "invalid money")
+}
+
+func TestConverterUnknownUnit(t *testing.T) {
+	_, err := NewQueryDecoder(struct {
+		Timeout time.Duration `query:"timeout,unit=weeks"`
+	}{}, "query")
+
+	assert.ErrorIs(t, err, ErrUnknownUnit)
+}
+
+func TestConverterUnsupportedOption(t *testing.T) {
+	_, err := NewQueryDecoder(struct {
+		Limit int `query:"limit,unit=ms"`
+	}{}, "query")
+	assert.ErrorIs(t, err, ErrUnsupportedOption)
+
+	_, err = NewQueryDecoder(struct {
+		Timeout []time.Duration `query:"timeout,layout=DateOnly"`
+	}{}, "query")
+	assert.ErrorIs(t, err, ErrUnsupportedOption)
+}
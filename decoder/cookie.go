@@ -5,14 +5,12 @@ import (
 )
 
 func NewCookieDecoder(v any, tag string) (Decode, error) {
-	dec, err := NewCachedDecoder(v, tag)
-	if err != nil {
-		return nil, err
-	}
+	return NewGetterDecoder(v, tag, CookieValues)
+}
 
-	return func(req *http.Request, v any) error {
-		return dec.Decode(CookieGetter(req.Cookies()), v)
-	}, nil
+// CookieValues returns the cookies of the request.
+func CookieValues(req *http.Request) Getter {
+	return CookieGetter(req.Cookies())
 }
 
 type CookieGetter []*http.Cookie
@@ -35,3 +33,20 @@ func (c CookieGetter) Values(key string) []string {
 	}
 	return res
 }
+
+func (c CookieGetter) Keys() []string {
+	keys := make([]string, len(c))
+	for i := range c {
+		keys[i] = c[i].Name
+	}
+	return keys
+}
+
+func (c CookieGetter) Has(key string) bool {
+	for i := range c {
+		if c[i].Name == key {
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,13 @@ package decoder
 
 import (
 	"net/http"
+	"strings"
 )
 
 // NewDefaultDecoder will set the default value of a struct value based on the tag value
-// int Property `default:"123"` will set the value 123
+// int Property `default:"123"` will set the value 123, slices take comma separated values `default:"1,2,3"`
 func NewDefaultDecoder(v any, tag string) (Decode, error) {
-	dec, err := NewCachedDecoder(v, tag)
+	dec, err := newCachedDecoder(v, &compiler{tagKey: tag, rawTag: true})
 	if err != nil {
 		return nil, err
 	}
@@ -24,5 +25,9 @@ func (_ defaultsGetter) Get(key string) string {
 }
 
 func (_ defaultsGetter) Values(key string) []string {
-	return []string{key}
+	return strings.Split(key, ",")
+}
+
+func (_ defaultsGetter) Has(key string) bool {
+	return true
 }
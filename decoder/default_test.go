@@ -0,0 +1,36 @@
+package decoder
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+type defaultTest struct {
+	String  string    `default:"hello, world"`
+	Int     int       `default:"10"`
+	Ints    []int     `default:"1,2,3"`
+	Strings []string  `default:"a,b"`
+	Array   [2]uint16 `default:"4,5"`
+}
+
+func TestDefaultDecoder(t *testing.T) {
+	dec, err := NewDefaultDecoder(defaultTest{}, "default")
+
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/foo", nil)
+
+	assert.NoError(t, err)
+
+	out := &defaultTest{}
+	err = dec(req, out)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "hello, world", out.String)
+	assert.Equal(t, 10, out.Int)
+	assert.Equal(t, []int{1, 2, 3}, out.Ints)
+	assert.Equal(t, []string{"a", "b"}, out.Strings)
+	assert.Equal(t, [2]uint16{4, 5}, out.Array)
+}
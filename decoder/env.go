@@ -0,0 +1,33 @@
+package decoder
+
+import (
+	"net/http"
+	"os"
+)
+
+func NewEnvDecoder(v any, tag string) (Decode, error) {
+	return NewGetterDecoder(v, tag, EnvValues)
+}
+
+// EnvValues returns the environment variables of the process, `env:"PAGE_SIZE"` binds the value of $PAGE_SIZE.
+func EnvValues(_ *http.Request) Getter {
+	return EnvGetter{}
+}
+
+type EnvGetter struct{}
+
+func (_ EnvGetter) Get(key string) string {
+	return os.Getenv(key)
+}
+
+func (_ EnvGetter) Values(key string) []string {
+	if v, ok := os.LookupEnv(key); ok {
+		return []string{v}
+	}
+	return nil
+}
+
+func (_ EnvGetter) Has(key string) bool {
+	_, ok := os.LookupEnv(key)
+	return ok
+}
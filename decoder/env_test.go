@@ -0,0 +1,41 @@
+package decoder
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+type envTest struct {
+	PageSize int      `env:"WEBAPP_TEST_PAGE_SIZE"`
+	Region   string   `env:"WEBAPP_TEST_REGION,required"`
+	Hosts    []string `env:"WEBAPP_TEST_HOSTS,comma-delimited"`
+	Missing  string   `env:"WEBAPP_TEST_MISSING"`
+}
+
+func TestEnvDecoder(t *testing.T) {
+	t.Setenv("WEBAPP_TEST_PAGE_SIZE", "25")
+	t.Setenv("WEBAPP_TEST_REGION", "eu-west")
+	t.Setenv("WEBAPP_TEST_HOSTS", "a.example.com,b.example.com")
+
+	dec, err := NewEnvDecoder(envTest{}, "env")
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	out := &envTest{Missing: "keep"}
+	assert.NoError(t, dec(req, out))
+
+	assert.Equal(t, 25, out.PageSize)
+	assert.Equal(t, "eu-west", out.Region)
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, out.Hosts)
+	assert.Equal(t, "keep", out.Missing)
+}
+
+func TestEnvDecoderRequired(t *testing.T) {
+	dec, err := NewEnvDecoder(envTest{}, "env")
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	assert.EqualError(t, dec(req, &envTest{}), `missing required env "WEBAPP_TEST_REGION"`)
+}
@@ -0,0 +1,54 @@
+package decoder
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// MissingError is returned when a value marked as required is missing.
+type MissingError struct {
+	// Source is the tag the value is bound from, e.g. query or header.
+	Source string
+	Key    string
+}
+
+func (e *MissingError) Error() string {
+	return "missing required " + e.Source + " " + strconv.Quote(e.Key)
+}
+
+func (e *MissingError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Message string `json:"message"`
+		Source  string `json:"source"`
+		Key     string `json:"key"`
+	}{e.Error(), e.Source, e.Key})
+}
+
+// UnknownParametersError is returned in strict mode for parameters that are not bound to any field.
+type UnknownParametersError struct {
+	Source   string
+	Unknown  []string
+	Accepted []string
+}
+
+func (e *UnknownParametersError) Error() string {
+	return "unknown " + e.Source + " parameters " + strings.Join(e.Unknown, ", ") + ", accepted are " + strings.Join(e.Accepted, ", ")
+}
+
+func (e *UnknownParametersError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Message  string   `json:"message"`
+		Source   string   `json:"source"`
+		Unknown  []string `json:"unknown"`
+		Accepted []string `json:"accepted"`
+	}{e.Error(), e.Source, nonNil(e.Unknown), nonNil(e.Accepted)})
+}
+
+// nonNil keeps empty lists as [] in the JSON output.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+package decoder
+
+import (
+	"reflect"
+	"sync"
+)
+
+// BindFunc binds the values of the getter into v, v is a pointer to the struct the func is registered for.
+type BindFunc func(g Getter, v any) error
+
+type bindKey struct {
+	typ reflect.Type
+	tag string
+}
+
+var bindFuncs sync.Map
+
+// RegisterBindFunc registers a specialised bind func of a tag for the struct type T, usually generated by webapp-gen.
+// Decoders created for T and the tag use the func instead of reflection, so register it before the handlers are created.
+func RegisterBindFunc[T any](tag string, fn func(g Getter, v *T) error) {
+	bindFuncs.Store(bindKey{typ: reflect.TypeOf((*T)(nil)).Elem(), tag: tag}, BindFunc(func(g Getter, v any) error {
+		switch p := v.(type) {
+		case *T:
+			return fn(g, p)
+		case **T:
+			if *p == nil {
+				*p = new(T)
+			}
+			return fn(g, *p)
+		}
+		return ErrUnsupportedType
+	}))
+}
+
+// lookupBindFunc returns the bind func of the type, nil when there is none or when a converter is registered for the
+// type of one of its fields. Bind funcs parse the builtin types themselves, reflection uses the converter.
+func lookupBindFunc(t reflect.Type, tag string) BindFunc {
+	fn, ok := bindFuncs.Load(bindKey{typ: t, tag: tag})
+	if !ok || hasConvertedFields(t, map[reflect.Type]bool{}) {
+		return nil
+	}
+	return fn.(BindFunc)
+}
+
+// hasConvertedFields reports if a converter is registered for the type of a field, the fields of nested structs and
+// the elements of slices included.
+func hasConvertedFields(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		ft, k, _ := typeKind(t.Field(i).Type)
+		if k == reflect.Slice || k == reflect.Array {
+			ft, k, _ = typeKind(ft.Elem())
+		}
+
+		if _, ok := converters.Load(ft); ok {
+			return true
+		}
+
+		if k == reflect.Struct && hasConvertedFields(ft, seen) {
+			return true
+		}
+	}
+	return false
+}
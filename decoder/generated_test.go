@@ -0,0 +1,64 @@
+package decoder_test
+
+import (
+	"github.com/mbict/go-webapp/decoder"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGeneratedBindFunc(t *testing.T) {
+	in := decoder.MapGetter{
+		"string":  {"string"},
+		"strings": {"a", "b"},
+		"int":     {"-1"},
+		"int8":    {"-8"},
+		"uint64":  {"64"},
+		"float32": {"1.5"},
+		"bool":    {"true"},
+	}
+
+	generated, err := decoder.NewCachedDecoder(benchRequest{}, "schema")
+	assert.NoError(t, err)
+
+	//the plain decoder always uses reflection
+	reflection := decoder.NewDecoder("schema")
+
+	var expected, actual benchRequest
+	assert.NoError(t, reflection.Decode(in, &expected))
+	assert.NoError(t, generated.Decode(in, &actual))
+	assert.Equal(t, expected, actual)
+
+	var ptr *benchRequest
+	assert.NoError(t, generated.Decode(in, &ptr))
+	assert.Equal(t, expected, *ptr)
+
+	err = generated.Decode(decoder.MapGetter{"int8": {"300"}}, &actual)
+	assert.Error(t, err)
+
+	//int64 is not limited to the size of int
+	err = generated.Decode(decoder.MapGetter{"int64": {"9223372036854775807"}}, &actual)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9223372036854775807), actual.Int64)
+}
+
+type convertedRequest struct {
+	Value uintptr `schema:"value"`
+}
+
+func TestGeneratedBindFuncConverter(t *testing.T) {
+	decoder.RegisterBindFunc("schema", func(g decoder.Getter, v *convertedRequest) error {
+		v.Value = 1
+		return nil
+	})
+	decoder.RegisterConverter(func(s string) (uintptr, error) {
+		return 2, nil
+	})
+
+	//the registered converter takes precedence over the bind func
+	dec, err := decoder.NewCachedDecoder(convertedRequest{}, "schema")
+	assert.NoError(t, err)
+
+	var out convertedRequest
+	assert.NoError(t, dec.Decode(decoder.MapGetter{"value": {"x"}}, &out))
+	assert.Equal(t, uintptr(2), out.Value)
+}
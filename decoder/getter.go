@@ -0,0 +1,24 @@
+package decoder
+
+import (
+	"net/http"
+)
+
+// GetterFunc returns the Getter with the values of the request, or nil when the request has none.
+type GetterFunc func(req *http.Request) Getter
+
+// NewGetterDecoder returns a decoder that binds the fields tagged with tag from the values of the getter.
+func NewGetterDecoder(v any, tag string, getter GetterFunc) (Decode, error) {
+	dec, err := NewCachedDecoder(v, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(req *http.Request, v any) error {
+		g := getter(req)
+		if g == nil {
+			return nil
+		}
+		return dec.Decode(g, v)
+	}, nil
+}
@@ -5,12 +5,34 @@ import (
 )
 
 func NewHeaderDecoder(v any, tag string) (Decode, error) {
-	dec, err := NewCachedDecoder(v, tag)
-	if err != nil {
-		return nil, err
+	return NewGetterDecoder(v, tag, HeaderValues)
+}
+
+// HeaderValues returns the headers of the request.
+func HeaderValues(req *http.Request) Getter {
+	return HeaderGetter(req.Header)
+}
+
+// HeaderGetter looks up the canonical header keys.
+type HeaderGetter http.Header
+
+func (h HeaderGetter) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+func (h HeaderGetter) Values(key string) []string {
+	return http.Header(h).Values(key)
+}
+
+func (h HeaderGetter) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
 	}
+	return keys
+}
 
-	return func(req *http.Request, v any) error {
-		return dec.Decode(req.Header, v)
-	}, nil
+func (h HeaderGetter) Has(key string) bool {
+	_, ok := h[http.CanonicalHeaderKey(key)]
+	return ok
 }
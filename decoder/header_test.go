@@ -75,3 +75,25 @@ func TestHeaderDecoder(t *testing.T) {
 	assert.Equal(t, out.Float64, float64(45.67))
 	assert.Equal(t, out.Bool, true)
 }
+
+func TestHeaderDecoderMap(t *testing.T) {
+	dec, err := NewHeaderDecoder(struct {
+		Meta map[string]string `header:"X-Meta-"`
+	}{}, "header")
+
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/foo", nil)
+	req.Header.Add("x-meta-color", "red")
+	req.Header.Add("X-Metadata", "ignored")
+
+	assert.NoError(t, err)
+
+	out := &struct {
+		Meta map[string]string `header:"X-Meta-"`
+	}{}
+	err = dec(req, out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Color": "red"}, out.Meta)
+}
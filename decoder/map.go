@@ -22,3 +22,16 @@ func (m MapGetter) Values(key string) []string {
 
 	return m[key]
 }
+
+func (m MapGetter) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (m MapGetter) Has(key string) bool {
+	_, ok := m[key]
+	return ok
+}
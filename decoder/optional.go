@@ -0,0 +1,164 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/goccy/go-json"
+	"reflect"
+)
+
+type presence uint8
+
+const (
+	absent presence = iota
+	empty
+	valued
+)
+
+// Optional tells an absent value apart from an empty one. A query ?name= or a JSON null is present but empty,
+// which allows clients to clear a value in PATCH like requests. Encoded as JSON an empty value is null, an absent
+// value is omitted with the omitzero option.
+//
+//	type UpdateUser struct {
+//		Name decoder.Optional[string] `query:"name" json:"name,omitzero"`
+//	}
+type Optional[T any] struct {
+	Value    T
+	presence presence
+}
+
+// Some returns an optional with the value present.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, presence: valued}
+}
+
+// Null returns an optional that is present without a value.
+func Null[T any]() Optional[T] {
+	return Optional[T]{presence: empty}
+}
+
+// Present reports if the value was provided, empty or not.
+func (o Optional[T]) Present() bool {
+	return o.presence != absent
+}
+
+// Empty reports if the value was provided without a value, e.g. ?name= or null.
+func (o Optional[T]) Empty() bool {
+	return o.presence == empty
+}
+
+// Get returns the value, and if it was provided with a value.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.presence == valued
+}
+
+func (o *Optional[T]) setPresence(p presence) {
+	if p != valued {
+		var zero T
+		o.Value = zero
+	}
+	o.presence = p
+}
+
+// IsZero reports if the value is absent, so the omitzero option omits absent values.
+func (o Optional[T]) IsZero() bool {
+	return o.presence == absent
+}
+
+// MarshalJSON renders absent and empty values as null, use the omitzero option to omit absent values.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if o.presence != valued {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		o.setPresence(empty)
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.presence = valued
+	return nil
+}
+
+// MarshalXML omits absent values and renders empty values as an empty element.
+func (o Optional[T]) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	switch o.presence {
+	case absent:
+		return nil
+	case empty:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		return enc.EncodeToken(start.End())
+	}
+	return enc.EncodeElement(o.Value, start)
+}
+
+// UnmarshalXML treats an element without content as empty. The attributes of the element are not decoded into the value.
+func (o *Optional[T]) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	var raw struct {
+		Content []byte `xml:",innerxml"`
+	}
+	if err := dec.DecodeElement(&raw, &start); err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(raw.Content)) == 0 {
+		o.setPresence(empty)
+		return nil
+	}
+
+	data := append(append([]byte("<v>"), raw.Content...), "</v>"...)
+	if err := xml.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.presence = valued
+	return nil
+}
+
+// optional is implemented by the pointer of every Optional.
+type optional interface {
+	setPresence(presence)
+}
+
+var optionalType = reflect.TypeOf((*optional)(nil)).Elem()
+
+func isOptional(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(optionalType)
+}
+
+// compileOptional compiles the decoder of an Optional field, the value is decoded as field 0 of the Optional.
+func (c *compiler) compileOptional(t reflect.Type, ptr bool, i int, tag string, options string, path []string) (decoder, error) {
+	vt, vk, vptr := typeKind(t.Field(0).Type)
+
+	dec, err := c.compileField(vt, vk, vptr, 0, tag, true, options, path)
+	if err != nil {
+		return nil, err
+	}
+
+	get := get(ptr, i, t)
+
+	return func(v reflect.Value, g Getter) error {
+		if !g.Has(tag) {
+			return nil
+		}
+
+		o := get(v)
+		if g.Get(tag) == "" {
+			o.Interface().(optional).setPresence(empty)
+			return nil
+		}
+
+		if err := dec(o.Elem(), g); err != nil {
+			return err
+		}
+		o.Interface().(optional).setPresence(valued)
+		return nil
+	}, nil
+}
@@ -0,0 +1,95 @@
+package decoder
+
+import (
+	"encoding/xml"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type optionalTest struct {
+	Name    Optional[string]     `query:"name" json:"name,omitzero" xml:"name"`
+	Limit   Optional[int]        `query:"limit" json:"limit,omitzero" xml:"limit"`
+	Since   *Optional[time.Time] `query:"since" json:"since,omitempty" xml:"since"`
+	Tags    Optional[[]string]   `query:"tags,comma-delimited" json:"tags,omitzero" xml:"tags"`
+	Ignored Optional[string]     `json:"-" xml:"-"`
+}
+
+func TestOptionalQuery(t *testing.T) {
+	dec, err := NewQueryDecoder(optionalTest{}, "query")
+
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/foo?name=&limit=10&tags=a,b", nil)
+
+	assert.NoError(t, err)
+
+	out := &optionalTest{Name: Some("previous")}
+	err = dec(req, out)
+
+	assert.NoError(t, err)
+
+	assert.True(t, out.Name.Present())
+	assert.True(t, out.Name.Empty())
+	assert.Equal(t, "", out.Name.Value)
+
+	limit, ok := out.Limit.Get()
+	assert.True(t, ok)
+	assert.Equal(t, 10, limit)
+
+	assert.Nil(t, out.Since)
+	assert.Equal(t, Some([]string{"a", "b"}), out.Tags)
+	assert.False(t, out.Ignored.Present())
+
+	req, _ = http.NewRequest("GET", "/foo?limit=abc", nil)
+	assert.Error(t, dec(req, &optionalTest{}))
+}
+
+func TestOptionalStrictQuery(t *testing.T) {
+	dec, err := NewStrictQueryDecoder(optionalTest{}, "query")
+
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/foo?name=&limit=10&since=2022-01-01T00:00:00Z&tags=a", nil)
+	assert.NoError(t, dec(req, nil))
+}
+
+func TestOptionalJSON(t *testing.T) {
+	out := optionalTest{}
+	err := json.Unmarshal([]byte(`{"name":null,"limit":5}`), &out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Null[string](), out.Name)
+	assert.Equal(t, Some(5), out.Limit)
+	assert.False(t, out.Tags.Present())
+
+	data, err := json.Marshal(out)
+
+	assert.NoError(t, err)
+	//absent values are omitted, empty values are null
+	assert.JSONEq(t, `{"name":null,"limit":5}`, string(data))
+
+	data, err = json.Marshal(struct {
+		Name Optional[string] `json:"name"`
+	}{})
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":null}`, string(data))
+}
+
+func TestOptionalXML(t *testing.T) {
+	out := optionalTest{}
+	err := xml.Unmarshal([]byte(`<optionalTest><name></name><limit>5</limit></optionalTest>`), &out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Null[string](), out.Name)
+	assert.Equal(t, Some(5), out.Limit)
+	assert.False(t, out.Tags.Present())
+
+	data, err := xml.Marshal(out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `<optionalTest><name></name><limit>5</limit></optionalTest>`, string(data))
+}
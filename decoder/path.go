@@ -3,18 +3,16 @@ package decoder
 import (
 	"github.com/mbict/httprouter"
 	"net/http"
+	"strings"
 )
 
 func NewPathDecoder(v any, tag string) (Decode, error) {
-	dec, err := NewCachedDecoder(v, tag)
-	if err != nil {
-		return nil, err
-	}
+	return NewGetterDecoder(v, tag, PathValues)
+}
 
-	return func(req *http.Request, v any) error {
-		params := httprouter.ParamsFromContext(req.Context())
-		return dec.Decode(ParamsGetter(params), v)
-	}, nil
+// PathValues returns the path parameters of the matched route.
+func PathValues(req *http.Request) Getter {
+	return ParamsGetter(httprouter.ParamsFromContext(req.Context()))
 }
 
 type ParamsGetter []httprouter.Param
@@ -28,11 +26,61 @@ func (ps ParamsGetter) Get(key string) string {
 	return ""
 }
 
+// Values returns the segments of a catch-all parameter, /files/*path binds a/b.txt as [a b.txt], and a single value
+// for the other parameters.
 func (ps ParamsGetter) Values(key string) []string {
 	for i := range ps {
 		if ps[i].Key == key {
+			//only catch-all values start with a slash
+			if strings.HasPrefix(ps[i].Value, "/") {
+				return segments(ps[i].Value)
+			}
 			return []string{ps[i].Value}
 		}
 	}
 	return nil
 }
+
+func segments(path string) []string {
+	res := []string{}
+	for _, s := range strings.Split(path, "/") {
+		if s != "" {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func (ps ParamsGetter) Keys() []string {
+	keys := make([]string, len(ps))
+	for i := range ps {
+		keys[i] = ps[i].Key
+	}
+	return keys
+}
+
+func (ps ParamsGetter) Has(key string) bool {
+	for i := range ps {
+		if ps[i].Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+type hostParamsKey struct{}
+
+// HostParamsKey is the request context key under which the parameters of the matched host pattern are stored.
+var HostParamsKey = hostParamsKey{}
+
+func NewHostDecoder(v any, tag string) (Decode, error) {
+	return NewGetterDecoder(v, tag, HostValues)
+}
+
+// HostValues returns the parameters of the matched host pattern, @tenant.example.com binds tenant.
+func HostValues(req *http.Request) Getter {
+	if params, ok := req.Context().Value(HostParamsKey).(ParamsGetter); ok {
+		return params
+	}
+	return nil
+}
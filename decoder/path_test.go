@@ -80,3 +80,28 @@ func TestPathDecoder(t *testing.T) {
 	assert.Equal(t, out.Float64, float64(45.67))
 	assert.Equal(t, out.Bool, true)
 }
+
+func TestPathDecoderCatchAll(t *testing.T) {
+	type catchAllTest struct {
+		Path     string   `path:"path"`
+		Segments []string `path:"path"`
+		Dir      []string `path:"dir"`
+	}
+
+	dec, err := NewPathDecoder(catchAllTest{}, "path")
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	params := httprouter.Params{
+		httprouter.Param{Key: "dir", Value: "js"},
+		httprouter.Param{Key: "path", Value: "/inc//framework.js"},
+	}
+	req = req.WithContext(context.WithValue(context.Background(), httprouter.ParamsKey, params))
+
+	out := &catchAllTest{}
+	assert.NoError(t, dec(req, out))
+
+	assert.Equal(t, "/inc//framework.js", out.Path)
+	assert.Equal(t, []string{"inc", "framework.js"}, out.Segments)
+	assert.Equal(t, []string{"js"}, out.Dir)
+}
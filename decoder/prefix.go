@@ -0,0 +1,124 @@
+package decoder
+
+import (
+	"strings"
+)
+
+// KeysGetter is a Getter that can list its keys, it is required to bind maps.
+type KeysGetter interface {
+	Getter
+	Keys() []string
+}
+
+// prefixGetter scopes a getter to the fields of a nested struct. A child key is looked up in the
+// dotted form first, filter.name, and in the deepObject form next, filter[name].
+type prefixGetter struct {
+	getter  Getter
+	dot     string
+	bracket string
+}
+
+func newPrefixGetter(g Getter, dot, bracket string) Getter {
+	//nested prefixes are precomputed, so we always wrap the getter of the request
+	if pg, ok := g.(*prefixGetter); ok {
+		g = pg.getter
+	}
+	return &prefixGetter{getter: g, dot: dot, bracket: bracket}
+}
+
+// prefixes returns the dotted and the deepObject prefix of the path, e.g. filter.range. and filter[range][
+func prefixes(path []string) (dot string, bracket string) {
+	dot = strings.Join(path, ".") + "."
+	bracket = path[0] + "["
+	for _, p := range path[1:] {
+		bracket += p + "]["
+	}
+	return dot, bracket
+}
+
+// bracketKey converts a relative key to its deepObject form, name becomes filter[name] and meta[a] becomes filter[meta][a].
+func (p *prefixGetter) bracketKey(key string) string {
+	if i := strings.IndexByte(key, '['); i >= 0 {
+		return p.bracket + key[:i] + "]" + key[i:]
+	}
+	return p.bracket + key + "]"
+}
+
+func (p *prefixGetter) Get(key string) string {
+	if s := p.getter.Get(p.dot + key); s != "" {
+		return s
+	}
+	return p.getter.Get(p.bracketKey(key))
+}
+
+func (p *prefixGetter) Values(key string) []string {
+	if s := p.getter.Values(p.dot + key); len(s) > 0 {
+		return s
+	}
+	return p.getter.Values(p.bracketKey(key))
+}
+
+func (p *prefixGetter) Has(key string) bool {
+	return p.getter.Has(p.dot+key) || p.getter.Has(p.bracketKey(key))
+}
+
+// Keys returns the keys within the prefix relative to it, filter[meta][a] becomes meta[a].
+func (p *prefixGetter) Keys() []string {
+	kg, ok := p.getter.(KeysGetter)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, key := range kg.Keys() {
+		if rel, ok := p.relativeKey(key); ok {
+			keys = append(keys, rel)
+		}
+	}
+	return keys
+}
+
+// relativeKey returns the key relative to the prefix, filter[meta][a] becomes meta[a].
+func (p *prefixGetter) relativeKey(key string) (string, bool) {
+	switch {
+	case strings.HasPrefix(key, p.dot):
+		return key[len(p.dot):], true
+	case strings.HasPrefix(key, p.bracket):
+		rest := key[len(p.bracket):]
+		if i := strings.IndexByte(rest, ']'); i > 0 {
+			return rest[:i] + rest[i+1:], true
+		}
+	}
+	return "", false
+}
+
+// mapKey returns the map key of a key collected by the map field, meta.a and meta[a] give a. Maps are not nested, so
+// keys with brackets in the map key like meta[a][b] are not collected. A name ending in a dash is a plain
+// case-insensitive prefix, the header X-Meta- collects X-Meta-Foo as Foo.
+func mapKey(name, key string) (string, bool) {
+	if strings.HasSuffix(name, "-") {
+		if len(key) > len(name) && strings.EqualFold(key[:len(name)], name) {
+			return key[len(name):], true
+		}
+		return "", false
+	}
+
+	if len(key) <= len(name)+1 || key[:len(name)] != name {
+		return "", false
+	}
+
+	rest := key[len(name):]
+	switch {
+	case rest[0] == '.':
+		rest = rest[1:]
+	case rest[0] == '[' && rest[len(rest)-1] == ']' && len(rest) > 2:
+		rest = rest[1 : len(rest)-1]
+	default:
+		return "", false
+	}
+
+	if strings.ContainsAny(rest, "[]") {
+		return "", false
+	}
+	return rest, true
+}
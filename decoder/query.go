@@ -5,12 +5,10 @@ import (
 )
 
 func NewQueryDecoder(v any, tag string) (Decode, error) {
-	dec, err := NewCachedDecoder(v, tag)
-	if err != nil {
-		return nil, err
-	}
+	return NewGetterDecoder(v, tag, QueryValues)
+}
 
-	return func(req *http.Request, v any) error {
-		return dec.Decode(MapGetter(req.URL.Query()), v)
-	}, nil
+// QueryValues returns the query parameters of the request.
+func QueryValues(req *http.Request) Getter {
+	return MapGetter(req.URL.Query())
 }
@@ -152,3 +152,162 @@ func TestQueryDecoderDates(t *testing.T) {
 	assert.Equal(t, []time.Time{firstDate, secondDate}, out.Dates)
 
 }
+
+type queryRange struct {
+	Min int `query:"min"`
+	Max int `query:"max"`
+}
+
+type queryFilter struct {
+	Name  string            `query:"name"`
+	Tags  []string          `query:"tags"`
+	Range *queryRange       `query:"range"`
+	Meta  map[string]string `query:"meta"`
+}
+
+type queryNested struct {
+	Name    string              `query:"name"`
+	Filter  queryFilter         `query:"filter"`
+	Exclude *queryFilter        `query:"exclude"`
+	Meta    map[string]string   `query:"meta"`
+	Multi   map[string][]string `query:"multi"`
+}
+
+func TestQueryDecoderNestedPrefix(t *testing.T) {
+	dec, err := NewQueryDecoder(queryNested{}, "query")
+
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/foo?name=root&filter.name=dotted&filter[tags]=a&filter[tags]=b&filter[range][min]=1&filter.range.max=9&exclude[name]=deep&filter[meta][color]=red&filter.meta.size=xl", nil)
+
+	assert.NoError(t, err)
+
+	out := &queryNested{}
+	err = dec(req, out)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, "root", out.Name)
+	assert.Equal(t, "dotted", out.Filter.Name)
+	assert.Equal(t, []string{"a", "b"}, out.Filter.Tags)
+	assert.Equal(t, &queryRange{Min: 1, Max: 9}, out.Filter.Range)
+	assert.Equal(t, map[string]string{"color": "red", "size": "xl"}, out.Filter.Meta)
+	assert.Equal(t, "deep", out.Exclude.Name)
+	assert.Nil(t, out.Meta)
+}
+
+func TestQueryDecoderMap(t *testing.T) {
+	dec, err := NewQueryDecoder(queryNested{}, "query")
+
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/foo?meta[a]=1&meta.b=2&meta=ignored&metadata=ignored&multi[x]=1&multi[x]=2", nil)
+
+	assert.NoError(t, err)
+
+	out := &queryNested{}
+	err = dec(req, out)
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, out.Meta)
+	assert.Equal(t, map[string][]string{"x": {"1", "2"}}, out.Multi)
+}
+
+func TestMapKey(t *testing.T) {
+	tests := map[string]struct {
+		key string
+		ok  bool
+	}{
+		"meta[a]":    {"a", true},
+		"meta.a":     {"a", true},
+		"meta.a.b":   {"a.b", true},
+		"meta[a][b]": {"", false},
+		"meta[a]b]":  {"", false},
+		"meta.a[b]":  {"", false},
+		"meta[]":     {"", false},
+		"meta":       {"", false},
+		"metadata":   {"", false},
+	}
+
+	for key, test := range tests {
+		k, ok := mapKey("meta", key)
+		assert.Equal(t, test.ok, ok, key)
+		assert.Equal(t, test.key, k, key)
+	}
+}
+
+func TestQueryDecoderNestedMapKeys(t *testing.T) {
+	dec, err := NewQueryDecoder(queryNested{}, "query")
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/foo?meta[a][b]=1&meta[c]=2", nil)
+	out := &queryNested{}
+	assert.NoError(t, dec(req, out))
+
+	assert.Equal(t, map[string]string{"c": "2"}, out.Meta)
+}
+
+func TestQueryDecoderUnsupportedMap(t *testing.T) {
+	_, err := NewQueryDecoder(struct {
+		Meta map[string]bool `query:"meta"`
+	}{}, "query")
+
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+type queryScalarSlices struct {
+	Ints       []int         `query:"ints"`
+	Int64s     []int64       `query:"int64s,comma-delimited"`
+	Uint8s     []uint8       `query:"uint8s,delimiter=pipe"`
+	Float64s   []float64     `query:"floats"`
+	Bools      []bool        `query:"bools,delimiter:comma"`
+	StringPtrs []*string     `query:"strings"`
+	IntPtrs    *[]int        `query:"ints"`
+	Array      [3]int        `query:"array,comma-delimited"`
+	Times      [2]*time.Time `query:"times"`
+}
+
+func TestQueryDecoderScalarSlices(t *testing.T) {
+	dec, err := NewQueryDecoder(queryScalarSlices{}, "query")
+
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest("GET", "/foo?ints=1&ints=2&ints=&int64s=3,4&uint8s=5|6&floats=1.5&bools=true,false&strings=a&strings=b&array=7,8&times=2006-01-02T15:04:05Z", nil)
+
+	assert.NoError(t, err)
+
+	out := &queryScalarSlices{Array: [3]int{1, 2, 3}}
+	err = dec(req, out)
+
+	assert.NoError(t, err)
+
+	firstDate, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05Z")
+
+	assert.Equal(t, []int{1, 2}, out.Ints)
+	assert.Equal(t, []int64{3, 4}, out.Int64s)
+	assert.Equal(t, []uint8{5, 6}, out.Uint8s)
+	assert.Equal(t, []float64{1.5}, out.Float64s)
+	assert.Equal(t, []bool{true, false}, out.Bools)
+	assert.Equal(t, []*string{asPtr("a"), asPtr("b")}, out.StringPtrs)
+	assert.Equal(t, &[]int{1, 2}, out.IntPtrs)
+	assert.Equal(t, [3]int{7, 8, 0}, out.Array)
+	assert.Equal(t, [2]*time.Time{&firstDate, nil}, out.Times)
+}
+
+func TestQueryDecoderScalarSliceErrors(t *testing.T) {
+	dec, err := NewQueryDecoder(queryScalarSlices{}, "query")
+
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/foo?ints=1&ints=abc", nil)
+	assert.Error(t, dec(req, &queryScalarSlices{}))
+
+	req, _ = http.NewRequest("GET", "/foo?array=1,2,3,4", nil)
+	assert.ErrorIs(t, dec(req, &queryScalarSlices{}), ErrTooManyValues)
+
+	_, err = NewQueryDecoder(struct {
+		Children []queryChild `query:"children"`
+	}{}, "query")
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
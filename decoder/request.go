@@ -1,28 +1,60 @@
 package decoder
 
 import (
+	"crypto/tls"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func NewRequestDecoder(v any, tag string) (Decode, error) {
-	dec, err := NewCachedDecoder(v, tag)
-	if err != nil {
-		return nil, err
-	}
+	return NewGetterDecoder(v, tag, RequestValues)
+}
 
-	return func(req *http.Request, v any) error {
-		return dec.Decode(&RequestGetter{Request: req}, v)
-	}, nil
+// RequestValues returns the properties of the request, like the method or the remote address.
+func RequestValues(req *http.Request) Getter {
+	return &RequestGetter{Request: req}
 }
 
+type requestIDKey struct{}
+
+// RequestIDKey is the request context key under which the request id is stored.
+var RequestIDKey = requestIDKey{}
+
+type routeKey struct{}
+
+// RouteKey is the request context key under which the pattern of the matched route is stored.
+var RouteKey = routeKey{}
+
 type RequestGetter struct {
 	*http.Request
 }
 
 func (c *RequestGetter) Get(key string) string {
 	switch key {
+	case `id`:
+		id, _ := c.Context().Value(RequestIDKey).(string)
+		return id
 	case `remote-addr`:
 		return (*c).RemoteAddr
+	case `client-ip`:
+		return ClientIP(c.Request)
+	case `proto`:
+		return (*c).Proto
+	case `content-length`:
+		if (*c).ContentLength < 0 {
+			return ""
+		}
+		return strconv.FormatInt((*c).ContentLength, 10)
+	case `user-agent`:
+		return c.UserAgent()
+	case `route`:
+		route, _ := c.Context().Value(RouteKey).(string)
+		return route
+	case `deadline`:
+		if deadline, ok := c.Context().Deadline(); ok {
+			return deadline.Format(time.RFC3339Nano)
+		}
 	case `host`:
 		return (*c).Host
 	case `method`:
@@ -37,14 +69,41 @@ func (c *RequestGetter) Get(key string) string {
 		return (*c).URL.Path
 	case `url:scheme`:
 		return (*c).URL.Scheme
+	case `url:fragment`:
+		return (*c).URL.Fragment
+	case `tls:server-name`:
+		if c.TLS != nil {
+			return c.TLS.ServerName
+		}
+	case `tls:version`:
+		if c.TLS != nil {
+			return tls.VersionName(c.TLS.Version)
+		}
+	case `tls:peer-cert-subject`:
+		if c.TLS != nil && len(c.TLS.PeerCertificates) > 0 {
+			return c.TLS.PeerCertificates[0].Subject.String()
+		}
 	}
 
 	return ""
 }
 
+// Values returns the subjects of the whole certificate chain for tls:peer-cert-subject, a single value for the other keys.
 func (c *RequestGetter) Values(key string) []string {
+	if key == `tls:peer-cert-subject` && c.TLS != nil {
+		var res []string
+		for _, cert := range c.TLS.PeerCertificates {
+			res = append(res, cert.Subject.String())
+		}
+		return res
+	}
+
 	if v := c.Get(key); v != "" {
 		return []string{v}
 	}
-	return []string{}
+	return nil
+}
+
+func (c *RequestGetter) Has(key string) bool {
+	return c.Get(key) != ""
 }
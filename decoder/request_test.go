@@ -1,9 +1,16 @@
 package decoder
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 type requestChild struct {
@@ -11,6 +18,7 @@ type requestChild struct {
 }
 
 type requestTest struct {
+	Id         string `request:"id"`
 	RemoteAddr string `request:"remote-addr"`
 	Host       string `request:"host"`
 	Method     string `request:"method"`
@@ -29,12 +37,14 @@ func TestRequestDecoder(t *testing.T) {
 	req, err := http.NewRequest("GET", "https://test.com/foo?bar=baz&foo=1", nil)
 
 	assert.NoError(t, err)
+	req = req.WithContext(context.WithValue(req.Context(), RequestIDKey, "abc-123"))
 
 	out := &requestTest{}
 	err = dec(req, out)
 
 	assert.NoError(t, err)
 
+	assert.Equal(t, "abc-123", out.Id)
 	assert.Equal(t, "", out.RemoteAddr)
 	assert.Equal(t, "test.com", out.Host)
 	assert.Equal(t, "GET", out.Method)
@@ -45,3 +55,114 @@ func TestRequestDecoder(t *testing.T) {
 	assert.Equal(t, "https", out.UrlSchema)
 
 }
+
+type requestInfoTest struct {
+	ClientIP      string    `request:"client-ip"`
+	Proto         string    `request:"proto"`
+	ContentLength int64     `request:"content-length"`
+	UserAgent     string    `request:"user-agent"`
+	Route         string    `request:"route"`
+	Fragment      string    `request:"url:fragment"`
+	ServerName    string    `request:"tls:server-name"`
+	TLSVersion    string    `request:"tls:version"`
+	Subjects      []string  `request:"tls:peer-cert-subject"`
+	Deadline      time.Time `request:"deadline"`
+}
+
+func TestRequestDecoderConnectionInfo(t *testing.T) {
+	dec, err := NewRequestDecoder(requestInfoTest{}, "request")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "https://test.com/foo", strings.NewReader("hello"))
+	req.URL.Fragment = "top"
+	req.Header.Set("User-Agent", "test-agent")
+	req.TLS.ServerName = "test.com"
+	req.TLS.Version = tls.VersionTLS13
+	req.TLS.PeerCertificates = []*x509.Certificate{
+		{Subject: pkix.Name{CommonName: "client"}},
+		{Subject: pkix.Name{CommonName: "ca"}},
+	}
+	req = req.WithContext(context.WithValue(req.Context(), RouteKey, "/foo"))
+
+	deadline := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	ctx, cancel := context.WithDeadline(req.Context(), deadline)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	out := &requestInfoTest{}
+	assert.NoError(t, dec(req, out))
+
+	assert.Equal(t, "192.0.2.1", out.ClientIP)
+	assert.Equal(t, "HTTP/1.1", out.Proto)
+	assert.Equal(t, int64(5), out.ContentLength)
+	assert.Equal(t, "test-agent", out.UserAgent)
+	assert.Equal(t, "/foo", out.Route)
+	assert.Equal(t, "top", out.Fragment)
+	assert.Equal(t, "test.com", out.ServerName)
+	assert.Equal(t, "TLS 1.3", out.TLSVersion)
+	assert.Equal(t, []string{"CN=client", "CN=ca"}, out.Subjects)
+	assert.True(t, deadline.Equal(out.Deadline))
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8", "2001:db8::1")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		message  string
+		remote   string
+		header   http.Header
+		expected string
+	}{
+		{
+			message:  "untrusted remote ignores forwarded headers",
+			remote:   "203.0.113.7:1234",
+			header:   http.Header{"X-Forwarded-For": {"198.51.100.1"}},
+			expected: "203.0.113.7",
+		},
+		{
+			message:  "trusted remote without forwarded headers",
+			remote:   "10.0.0.1:1234",
+			expected: "10.0.0.1",
+		},
+		{
+			message:  "last untrusted address of x-forwarded-for",
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"X-Forwarded-For": {"198.51.100.99, 198.51.100.1", "10.0.0.2"}},
+			expected: "198.51.100.1",
+		},
+		{
+			message:  "all forwarded addresses are trusted",
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}},
+			expected: "10.0.0.3",
+		},
+		{
+			message:  "forwarded takes precedence over x-forwarded-for",
+			remote:   "[2001:db8::1]:443",
+			header:   http.Header{"Forwarded": {`for=198.51.100.1;proto=https, For="[2001:db8:cafe::17]:4711"`}, "X-Forwarded-For": {"198.51.100.2"}},
+			expected: "2001:db8:cafe::17",
+		},
+		{
+			message:  "obfuscated client",
+			remote:   "10.0.0.1:1234",
+			header:   http.Header{"Forwarded": {"for=unknown"}},
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = test.remote
+		req.Header = test.header
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		req = req.WithContext(context.WithValue(req.Context(), TrustedProxiesKey, proxies))
+
+		assert.Equal(t, test.expected, ClientIP(req), test.message)
+	}
+
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+}
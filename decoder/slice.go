@@ -0,0 +1,242 @@
+package decoder
+
+import (
+	"encoding"
+	"github.com/mbict/go-webapp/internal"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// compileSlice compiles the decoder of a slice or array field, the elements can be of any kind
+// that is supported as a single value.
+//
+//nolint:cyclop
+func compileSlice(t reflect.Type, ptr bool, i int, k string, options string) (decoder, error) {
+	et, ek, eptr := typeKind(t.Elem())
+	delimiter := getDelimiterFromOptions(options)
+
+	conv, err := converterFor(et, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if conv != nil {
+		return conv.list(t, ptr, i, k, delimiter, eptr)
+	}
+
+	//elements with a text unmarshaller, time and uuid for example
+	if reflect.PointerTo(et).Implements(unmarshalerType) {
+		return decodeTextUnmarshalers(get(ptr, i, t), k, delimiter, et, eptr), nil
+	}
+
+	switch ek {
+	case reflect.String:
+		if t.Kind() == reflect.Slice && !eptr {
+			return decodeStrings(set[[]string](ptr, i, t), k, delimiter), nil
+		}
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseString)
+	case reflect.Int:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, strconv.Atoi)
+	case reflect.Int8:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseInt[int8](8))
+	case reflect.Int16:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseInt[int16](16))
+	case reflect.Int32:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseInt[int32](32))
+	case reflect.Int64:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseInt[int64](64))
+	case reflect.Uint:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseUint[uint](strconv.IntSize))
+	case reflect.Uint8:
+		//raw bytes, unless delimited numbers are expected
+		if t.Kind() == reflect.Slice && !eptr && delimiter == "" {
+			return decodeBytes(set[[]byte](ptr, i, t), k), nil
+		}
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseUint[uint8](8))
+	case reflect.Uint16:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseUint[uint16](16))
+	case reflect.Uint32:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseUint[uint32](32))
+	case reflect.Uint64:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseUint[uint64](64))
+	case reflect.Float32:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseFloat[float32](32))
+	case reflect.Float64:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, parseFloat[float64](64))
+	case reflect.Bool:
+		return sliceDecoder(t, ptr, i, k, delimiter, eptr, strconv.ParseBool)
+	}
+
+	return nil, ErrUnsupportedType
+}
+
+// sliceDecoder returns the decoder for a slice or array with elements of type T, or pointers to T.
+func sliceDecoder[T any](t reflect.Type, ptr bool, i int, k string, delimiter string, elemPtr bool, parse func(string) (T, error)) (decoder, error) {
+	if elemPtr {
+		return listDecoder(t, ptr, i, k, delimiter, func(s string) (*T, error) {
+			v, err := parse(s)
+			if err != nil {
+				return nil, err
+			}
+			return &v, nil
+		}), nil
+	}
+	return listDecoder(t, ptr, i, k, delimiter, parse), nil
+}
+
+func listDecoder[T any](t reflect.Type, ptr bool, i int, k string, delimiter string, parse func(string) (T, error)) decoder {
+	if t.Kind() == reflect.Array {
+		return decodeArray(get(ptr, i, t), k, delimiter, t.Len(), parse)
+	}
+	return decodeSlice(set[[]T](ptr, i, t), k, delimiter, parse)
+}
+
+// decodeSlice parses all values into a new slice, empty values are skipped just as for single values.
+func decodeSlice[T any](set func(reflect.Value, []T), k string, delimiter string, parse func(string) (T, error)) decoder {
+	return func(v reflect.Value, g Getter) error {
+		s := splitValues(g.Values(k), delimiter)
+		if len(s) == 0 {
+			return nil
+		}
+
+		res := make([]T, 0, len(s))
+		for _, value := range s {
+			if value == "" {
+				continue
+			}
+
+			n, err := parse(value)
+			if err != nil {
+				return err
+			}
+			res = append(res, n)
+		}
+
+		if len(res) > 0 {
+			set(v, res)
+		}
+		return nil
+	}
+}
+
+// decodeArray parses the values into the array, elements without a value are zeroed.
+func decodeArray[T any](get func(reflect.Value) reflect.Value, k string, delimiter string, n int, parse func(string) (T, error)) decoder {
+	return func(v reflect.Value, g Getter) error {
+		s := splitValues(g.Values(k), delimiter)
+		if len(s) == 0 {
+			return nil
+		}
+
+		res := make([]T, 0, n)
+		for _, value := range s {
+			if value == "" {
+				continue
+			}
+
+			if len(res) == n {
+				return ErrTooManyValues
+			}
+
+			e, err := parse(value)
+			if err != nil {
+				return err
+			}
+			res = append(res, e)
+		}
+
+		if len(res) > 0 {
+			arr := unsafe.Slice((*T)(get(v).UnsafePointer()), n)
+			copy(arr, res)
+
+			var zero T
+			for j := len(res); j < n; j++ {
+				arr[j] = zero
+			}
+		}
+		return nil
+	}
+}
+
+// decodeTextUnmarshalers decodes a slice or array of text unmarshalers, elements can be pointers.
+func decodeTextUnmarshalers(get func(reflect.Value) reflect.Value, k string, delimiter string, elemType reflect.Type, elemPtr bool) decoder {
+	return func(v reflect.Value, g Getter) error {
+		s := splitValues(g.Values(k), delimiter)
+
+		values := make([]reflect.Value, 0, len(s))
+		for _, val := range s {
+			if val == "" {
+				continue
+			}
+
+			e := reflect.New(elemType)
+			if err := e.Interface().(encoding.TextUnmarshaler).UnmarshalText(internal.Atob(val)); err != nil {
+				return err
+			}
+
+			if !elemPtr {
+				e = e.Elem()
+			}
+			values = append(values, e)
+		}
+
+		if len(values) == 0 {
+			return nil
+		}
+
+		f := get(v).Elem()
+		if f.Kind() == reflect.Slice {
+			f.Set(reflect.Append(reflect.MakeSlice(f.Type(), 0, len(values)), values...))
+			return nil
+		}
+
+		if len(values) > f.Len() {
+			return ErrTooManyValues
+		}
+
+		f.SetZero()
+		for j, e := range values {
+			f.Index(j).Set(e)
+		}
+		return nil
+	}
+}
+
+// splitValues splits every value by the delimiter, if any.
+func splitValues(s []string, delimiter string) []string {
+	if delimiter == "" {
+		return s
+	}
+
+	var res []string
+	for _, value := range s {
+		res = append(res, strings.Split(value, delimiter)...)
+	}
+	return res
+}
+
+func parseString(s string) (string, error) {
+	return s, nil
+}
+
+func parseInt[T int8 | int16 | int32 | int64](bitSize int) func(string) (T, error) {
+	return func(s string) (T, error) {
+		n, err := strconv.ParseInt(s, 10, bitSize)
+		return T(n), err
+	}
+}
+
+func parseUint[T uint | uint8 | uint16 | uint32 | uint64](bitSize int) func(string) (T, error) {
+	return func(s string) (T, error) {
+		n, err := strconv.ParseUint(s, 10, bitSize)
+		return T(n), err
+	}
+}
+
+func parseFloat[T float32 | float64](bitSize int) func(string) (T, error) {
+	return func(s string) (T, error) {
+		f, err := strconv.ParseFloat(s, bitSize)
+		return T(f), err
+	}
+}
@@ -0,0 +1,145 @@
+package decoder
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// TagKeys returns the keys of the tag a field binds through another tag, e.g. the query keys in a fallback chain.
+type TagKeys func(field reflect.StructField) []string
+
+// NewStrictQueryDecoder returns a decoder that rejects the query parameters that are not bound to a field of v
+// with the tag or one of the chained keys. It does not decode any values, use it next to the query decoder.
+func NewStrictQueryDecoder(v any, tag string, chained ...TagKeys) (Decode, error) {
+	t, k, _ := typeKind(reflect.TypeOf(v))
+	if k != reflect.Struct {
+		return nil, ErrUnsupportedType
+	}
+
+	keys := &acceptedKeys{exact: map[string]struct{}{}, chained: chained}
+	if err := keys.collect(t, tag, nil); err != nil {
+		return nil, err
+	}
+	sort.Strings(keys.names)
+
+	return func(req *http.Request, _ any) error {
+		var unknown []string
+		for key := range req.URL.Query() {
+			if !keys.accepts(key) {
+				unknown = append(unknown, key)
+			}
+		}
+
+		if len(unknown) == 0 {
+			return nil
+		}
+
+		sort.Strings(unknown)
+		return &UnknownParametersError{Source: tag, Unknown: unknown, Accepted: keys.names}
+	}, nil
+}
+
+// acceptedKeys are the keys a struct binds, maps accept every key within their name.
+type acceptedKeys struct {
+	exact   map[string]struct{}
+	maps    []mapMatcher
+	names   []string
+	chained []TagKeys
+}
+
+type mapMatcher struct {
+	prefix *prefixGetter
+	name   string
+}
+
+func (a *acceptedKeys) collect(typ reflect.Type, tagKey string, path []string) error {
+	prefix := &prefixGetter{}
+	if len(path) > 0 {
+		prefix.dot, prefix.bracket = prefixes(path)
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue // skip unexported fields
+		}
+
+		t, k, ptr := typeKind(f.Type)
+
+		tag, ok := f.Tag.Lookup(tagKey)
+		tag, options := parseTag(tag)
+
+		var keys []string
+		if ok {
+			keys = append(keys, tag)
+		}
+		for _, chained := range a.chained {
+			keys = append(keys, chained(f)...)
+		}
+
+		if len(keys) == 0 && k != reflect.Struct {
+			continue
+		}
+
+		conv, err := converterFor(t, options)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case conv != nil || isOptional(t) || reflect.PointerTo(t).Implements(unmarshalerType):
+			for _, key := range keys {
+				a.add(prefix, key)
+			}
+		case k == reflect.Struct:
+			childPath := path
+			if ok && tag != "" {
+				childPath = append(path[:len(path):len(path)], tag)
+			}
+
+			if err := a.collect(t, tagKey, childPath); err != nil {
+				return err
+			}
+		case k == reflect.Map && !ptr:
+			for _, key := range keys {
+				a.maps = append(a.maps, mapMatcher{prefix: prefix, name: key})
+				a.names = append(a.names, prefix.dot+key+"[*]")
+			}
+		default:
+			for _, key := range keys {
+				a.add(prefix, key)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *acceptedKeys) add(prefix *prefixGetter, k string) {
+	if _, ok := a.exact[prefix.dot+k]; ok {
+		return
+	}
+
+	if prefix.dot == "" {
+		a.exact[k] = struct{}{}
+	} else {
+		a.exact[prefix.dot+k] = struct{}{}
+		a.exact[prefix.bracketKey(k)] = struct{}{}
+	}
+	a.names = append(a.names, prefix.dot+k)
+}
+
+func (a *acceptedKeys) accepts(key string) bool {
+	if _, ok := a.exact[key]; ok {
+		return true
+	}
+
+	for _, m := range a.maps {
+		if rel, ok := m.prefix.relativeKey(key); ok {
+			if _, ok := mapKey(m.name, rel); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
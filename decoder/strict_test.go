@@ -0,0 +1,131 @@
+package decoder
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type strictTest struct {
+	Name   string            `query:"name,required"`
+	Limit  int               `query:"limit"`
+	Since  time.Time         `query:"since"`
+	Filter queryFilter       `query:"filter"`
+	Meta   map[string]string `query:"meta"`
+	Nested queryChild
+}
+
+func TestRequired(t *testing.T) {
+	dec, err := NewQueryDecoder(strictTest{}, "query")
+
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/foo?limit=1", nil)
+	err = dec(req, &strictTest{})
+
+	assert.Equal(t, &MissingError{Source: "query", Key: "name"}, err)
+	assert.EqualError(t, err, `missing required query "name"`)
+
+	req, _ = http.NewRequest("GET", "/foo?name=test", nil)
+	assert.NoError(t, dec(req, &strictTest{}))
+}
+
+func TestRequiredNested(t *testing.T) {
+	type filter struct {
+		Name string            `query:"name,required"`
+		Meta map[string]string `query:"meta,required"`
+	}
+
+	dec, err := NewQueryDecoder(struct {
+		Filter filter `query:"filter"`
+	}{}, "query")
+
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/foo?filter[meta][a]=1", nil)
+	assert.Equal(t, &MissingError{Source: "query", Key: "filter.name"}, dec(req, &struct {
+		Filter filter `query:"filter"`
+	}{}))
+
+	req, _ = http.NewRequest("GET", "/foo?filter[name]=a", nil)
+	assert.Equal(t, &MissingError{Source: "query", Key: "filter.meta"}, dec(req, &struct {
+		Filter filter `query:"filter"`
+	}{}))
+}
+
+func TestRequiredValueStructs(t *testing.T) {
+	type required struct {
+		Since time.Time        `query:"since,required"`
+		Limit Optional[int]    `query:"limit,required"`
+		Sort  Optional[string] `query:"sort"`
+	}
+
+	dec, err := NewQueryDecoder(required{}, "query")
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/foo?limit=1", nil)
+	assert.Equal(t, &MissingError{Source: "query", Key: "since"}, dec(req, &required{}))
+
+	req, _ = http.NewRequest("GET", "/foo?since=2024-01-01T00:00:00Z", nil)
+	assert.Equal(t, &MissingError{Source: "query", Key: "limit"}, dec(req, &required{}))
+
+	req, _ = http.NewRequest("GET", "/foo?since=2024-01-01T00:00:00Z&limit=1", nil)
+	assert.NoError(t, dec(req, &required{}))
+
+	//a nested struct is not a value
+	_, err = NewQueryDecoder(struct {
+		Filter queryFilter `query:"filter,required"`
+	}{}, "query")
+	assert.ErrorIs(t, err, ErrUnsupportedOption)
+}
+
+func TestErrorsMarshalJSON(t *testing.T) {
+	var missing struct {
+		Message string `json:"message"`
+		Key     string `json:"key"`
+	}
+	missingErr := &MissingError{Source: "query", Key: "\x01\u2028"}
+	data, err := missingErr.MarshalJSON()
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &missing))
+	assert.Equal(t, missingErr.Error(), missing.Message)
+	assert.Equal(t, "\x01\u2028", missing.Key)
+
+	var unknown struct {
+		Unknown  []string `json:"unknown"`
+		Accepted []string `json:"accepted"`
+	}
+	data, err = (&UnknownParametersError{Source: "query", Unknown: []string{"\x01"}}).MarshalJSON()
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &unknown))
+	assert.Equal(t, []string{"\x01"}, unknown.Unknown)
+	assert.Equal(t, []string{}, unknown.Accepted)
+}
+
+func TestStrictQuery(t *testing.T) {
+	dec, err := NewStrictQueryDecoder(strictTest{}, "query")
+
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/foo?name=a&limit=1&since=x&string=x&filter.name=a&filter[range][min]=1&filter[meta][a]=1&meta[b]=2", nil)
+	assert.NoError(t, dec(req, nil))
+
+	req, _ = http.NewRequest("GET", "/foo?name=a&foo=1&filter.unknown=2&metadata=3", nil)
+	err = dec(req, nil)
+
+	assert.Equal(t, &UnknownParametersError{
+		Source:   "query",
+		Unknown:  []string{"filter.unknown", "foo", "metadata"},
+		Accepted: []string{"filter.meta[*]", "filter.name", "filter.range.max", "filter.range.min", "filter.tags", "limit", "meta[*]", "name", "since", "string"},
+	}, err)
+
+	data, _ := err.(*UnknownParametersError).MarshalJSON()
+	assert.JSONEq(t, `{
+		"message": "unknown query parameters filter.unknown, foo, metadata, accepted are filter.meta[*], filter.name, filter.range.max, filter.range.min, filter.tags, limit, meta[*], name, since, string",
+		"source": "query",
+		"unknown": ["filter.unknown", "foo", "metadata"],
+		"accepted": ["filter.meta[*]", "filter.name", "filter.range.max", "filter.range.min", "filter.tags", "limit", "meta[*]", "name", "since", "string"]
+	}`, string(data))
+}
@@ -9,13 +9,55 @@ type Decoder interface {
 	Decode(req *http.Request, v any) error
 }
 
-type decoders []decoder.Decode
+// argumentDecoder is the decoder of a single binding tag, e.g. the query decoder.
+type argumentDecoder struct {
+	tag    string
+	span   string
+	decode decoder.Decode
+}
+
+type decoders []argumentDecoder
 
+// Decode decodes the sources in the order of registration, handlers created with H decode in the order of
+// WithSourceOrder.
 func (d *decoders) Decode(req *http.Request, v any) error {
-	for _, decodeFunc := range *d {
-		if err := decodeFunc(req, v); err != nil {
-			return err
+	return d.decodeInOrder(req, v, nil)
+}
+
+// decodeInOrder decodes the sources missing in the order first, followed by the ones in the order.
+func (d *decoders) decodeInOrder(req *http.Request, v any, order []string) error {
+	for _, dec := range *d {
+		if !contains(order, dec.tag) {
+			if err := dec.run(req, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, tag := range order {
+		for _, dec := range *d {
+			if dec.tag == tag {
+				if err := dec.run(req, v); err != nil {
+					return err
+				}
+			}
 		}
 	}
 	return nil
 }
+
+func (dec *argumentDecoder) run(req *http.Request, v any) error {
+	tagReq, span := startSpan(req, dec.span)
+	err := dec.decode(tagReq, v)
+	endSpan(span, err)
+	return err
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -3,9 +3,8 @@ package webapp
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"encoding/xml"
-	"github.com/mbict/go-webapp/encoding/json"
-	"log"
 	"net/http"
 	"strconv"
 )
@@ -19,35 +18,15 @@ func E(err error) http.HandlerFunc {
 	}
 }
 
-func ErrorHandler() func(error) http.HandlerFunc {
-	encoderNegotiator := NewNegotiatorBuilder[Encoder]()
-	jsonEncoder := json.NewJsonEncoding()
-	encoderNegotiator.Register("application/json", jsonEncoder, "*/*")
+// ErrorHandler returns a factory for handlers that render an error, negotiated and logged the same way as
+// errors returned by handlers created with H. Like H the options are stacked on the options of the API and groups
+// of the request, see UseOptions.
+func ErrorHandler(options ...Option) func(error) http.HandlerFunc {
+	contexts := newHandlerContexts(options)
 
 	return func(e error) http.HandlerFunc {
 		return func(rw http.ResponseWriter, req *http.Request) {
-			enc, err := encoderNegotiator.Get(req.Header.Get("Accept"))
-			//default to json encoder if no negotiation cna be done
-			if err != nil {
-				enc = jsonEncoder
-			}
-
-			rw.Header().Add("Content-Type", enc.Mimetype()+"; charset=utf-8")
-
-			if h, ok := e.(Headerer); ok {
-				for k, v := range h.Header() {
-					rw.Header().Add(k, v[0])
-				}
-			}
-
-			if sc, ok := e.(StatusCoder); ok {
-				rw.WriteHeader(sc.StatusCode())
-			}
-
-			if err = enc.Encode(rw, e); err != nil {
-				log.Default().Printf("%v", err)
-				http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-			}
+			contexts.of(req).handleError(e, rw, req)
 		}
 	}
 }
@@ -68,6 +47,10 @@ func (e *HTTPError) Error() string {
 	return e.err.Error()
 }
 
+func (e *HTTPError) Unwrap() error {
+	return e.err
+}
+
 func (e *HTTPError) StatusCode() int {
 	if sc, ok := e.err.(StatusCoder); ok {
 		return sc.StatusCode()
@@ -84,7 +67,12 @@ func (e *HTTPError) MarshalText() ([]byte, error) {
 	return []byte(e.Error()), nil
 }
 
+// MarshalJSON renders the message of the error, errors with their own JSON representation render that one.
 func (e *HTTPError) MarshalJSON() ([]byte, error) {
+	if m, ok := e.err.(json.Marshaler); ok {
+		return m.MarshalJSON()
+	}
+
 	var buf bytes.Buffer
 
 	buf.WriteString(`{"message":`)
@@ -115,8 +103,13 @@ const (
 	ErrNotFound             = StatusError(http.StatusNotFound)
 	ErrMethodNotAllowed     = StatusError(http.StatusMethodNotAllowed)
 	ErrNotAcceptable        = StatusError(http.StatusNotAcceptable)
+	ErrConflict             = StatusError(http.StatusConflict)
 	ErrUnsupportedMediaType = StatusError(http.StatusUnsupportedMediaType)
+	ErrUnprocessableEntity  = StatusError(http.StatusUnprocessableEntity)
+	ErrTooManyRequests      = StatusError(http.StatusTooManyRequests)
 	ErrInternalServerError  = StatusError(http.StatusInternalServerError)
+	ErrServiceUnavailable   = StatusError(http.StatusServiceUnavailable)
+	ErrGatewayTimeout       = StatusError(http.StatusGatewayTimeout)
 )
 
 func (e StatusError) Error() string {
@@ -0,0 +1,160 @@
+package webapp
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+)
+
+// ErrorMapping describes the response rendered for an error.
+type ErrorMapping struct {
+	//Status is the status code of the response
+	Status int
+	//Type is the URI identifying the type of the problem, omitted when empty
+	Type string
+	//Message is the public message of the error, the status text is used when empty
+	Message string
+}
+
+type errorMapping struct {
+	match   func(error) bool
+	mapping ErrorMapping
+}
+
+// MapError maps errors matching the target with errors.Is, e.g. sql.ErrNoRows, to the mapping.
+// Mappings added later take precedence, so a group can override the mappings of the API.
+//
+//	api := webapp.New(nil, webapp.MapError(sql.ErrNoRows, webapp.ErrorMapping{Status: http.StatusNotFound}))
+func MapError(target error, mapping ErrorMapping) Option {
+	return mapErrors(func(err error) bool {
+		return errors.Is(err, target)
+	}, mapping)
+}
+
+// MapErrorType maps errors of type E, matched with errors.As, to the mapping. This allows domain errors to be
+// mapped to a status without implementing StatusCoder.
+//
+//	webapp.MapErrorType[*orders.ConflictError](webapp.ErrorMapping{Status: http.StatusConflict})
+func MapErrorType[E error](mapping ErrorMapping) Option {
+	return mapErrors(func(err error) bool {
+		var target E
+		return errors.As(err, &target)
+	}, mapping)
+}
+
+func mapErrors(match func(error) bool, mapping ErrorMapping) Option {
+	return func(ctx *HandlerContext) {
+		ctx.errorMappings = append(ctx.errorMappings, errorMapping{match: match, mapping: mapping})
+	}
+}
+
+// DebugErrors renders the details of internal errors, by default only their status text is rendered.
+func DebugErrors() Option {
+	return func(ctx *HandlerContext) {
+		ctx.debugErrors = true
+	}
+}
+
+// ErrorMapper returns a func that maps errors like a handler created with the options responds with them, with the
+// error mappings, error handler and debug mode of the option layer of the context. Errors that are not written as
+// a response, e.g. the errors of background jobs, are exposed with the same public message.
+func ErrorMapper(options ...Option) func(ctx context.Context, err error) error {
+	contexts := newHandlerContexts(options)
+	return func(ctx context.Context, err error) error {
+		return contexts.ofContext(ctx).mapError(err)
+	}
+}
+
+// mapError maps the error with the error mappings, errors without a mapping are passed to the error handler.
+// Errors that are a StatusCoder keep their status, the details of server errors are hidden outside debug mode.
+func (ctx *HandlerContext) mapError(err error) error {
+	if _, ok := err.(StatusCoder); !ok {
+		for i := len(ctx.errorMappings) - 1; i >= 0; i-- {
+			if m := ctx.errorMappings[i]; m.match(err) {
+				return &MappedError{ErrorMapping: m.mapping, Err: err, debug: ctx.debugErrors}
+			}
+		}
+	}
+
+	err = ctx.errorHandler(err)
+	if _, ok := err.(*MappedError); ok || ctx.debugErrors {
+		return err
+	}
+
+	//hide the details of internal errors, also when they come from a custom error handler
+	status := http.StatusInternalServerError
+	if sc, ok := err.(StatusCoder); ok {
+		status = sc.StatusCode()
+	}
+	if status >= http.StatusInternalServerError {
+		return &MappedError{ErrorMapping: ErrorMapping{Status: status}, Err: err}
+	}
+	return err
+}
+
+// MappedError is an error rendered with its mapping, the message of the error itself is only rendered in debug mode.
+type MappedError struct {
+	ErrorMapping
+	Err   error
+	debug bool
+}
+
+func (e *MappedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MappedError) Unwrap() error {
+	return e.Err
+}
+
+// Header returns the headers of the error, e.g. the Retry-After of a 503.
+func (e *MappedError) Header() http.Header {
+	var h Headerer
+	if errors.As(e.Err, &h) {
+		return h.Header()
+	}
+	return nil
+}
+
+func (e *MappedError) StatusCode() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+type problem struct {
+	XMLName xml.Name `json:"-" xml:"error" yaml:"-"`
+	Type    string   `json:"type,omitempty" xml:"type,omitempty" yaml:"type,omitempty"`
+	Message string   `json:"message" xml:"message" yaml:"message"`
+	Detail  string   `json:"detail,omitempty" xml:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+func (e *MappedError) problem() problem {
+	p := problem{Type: e.Type, Message: e.Message}
+	if p.Message == "" {
+		p.Message = http.StatusText(e.StatusCode())
+	}
+	if e.debug {
+		p.Detail = e.Err.Error()
+	}
+	return p
+}
+
+func (e *MappedError) MarshalText() ([]byte, error) {
+	return []byte(e.problem().Message), nil
+}
+
+func (e *MappedError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.problem())
+}
+
+func (e *MappedError) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return enc.Encode(e.problem())
+}
+
+func (e *MappedError) MarshalYAML() (interface{}, error) {
+	return e.problem(), nil
+}
@@ -0,0 +1,102 @@
+package webapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type conflictError struct {
+	id string
+}
+
+func (e *conflictError) Error() string {
+	return "order " + e.id + " already exists"
+}
+
+// unavailableError is a server error with its own status and message.
+type unavailableError struct{}
+
+func (e *unavailableError) Error() string {
+	return "replica lag of 30s"
+}
+
+func (e *unavailableError) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
+
+func (e *unavailableError) Header() http.Header {
+	return http.Header{"Retry-After": {"30"}}
+}
+
+func TestMapError(t *testing.T) {
+	api := New(nil,
+		MapError(sql.ErrNoRows, ErrorMapping{Status: http.StatusNotFound, Type: "https://example.com/problems/not-found"}),
+		MapErrorType[*conflictError](ErrorMapping{Status: http.StatusConflict, Message: "order already exists"}),
+	)
+
+	fail := func(err error) http.HandlerFunc {
+		return H(func(ctx context.Context, req Empty) (*Empty, error) {
+			return nil, err
+		})
+	}
+
+	api.Get("/missing", fail(fmt.Errorf("find order: %w", sql.ErrNoRows)))
+	api.Get("/conflict", fail(fmt.Errorf("create order: %w", &conflictError{id: "1"})))
+	api.Get("/timeout", fail(context.DeadlineExceeded))
+	api.Get("/internal", fail(errors.New("connection refused")))
+	api.Get("/status", fail(Error(sql.ErrNoRows, http.StatusBadRequest)))
+
+	api.Get("/unavailable", fail(&unavailableError{}))
+
+	group := api.Group("/group", UseOptions(MapError(sql.ErrNoRows, ErrorMapping{Status: http.StatusGone})))
+	group.Get("/missing", fail(sql.ErrNoRows))
+
+	custom := api.Group("/custom", UseOptions(WithErrorHandler(func(err error) error {
+		return fmt.Errorf("handled: %w", err)
+	})))
+	custom.Get("/internal", fail(errors.New("connection refused")))
+
+	debug := api.Group("/debug", UseOptions(DebugErrors()))
+	debug.Get("/internal", fail(errors.New("connection refused")))
+	debug.Get("/missing", fail(sql.ErrNoRows))
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	tests := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/missing", http.StatusNotFound, `{"type":"https://example.com/problems/not-found","message":"Not Found"}`},
+		{"/conflict", http.StatusConflict, `{"message":"order already exists"}`},
+		{"/timeout", http.StatusGatewayTimeout, `{"message":"Gateway Timeout"}`},
+		{"/internal", http.StatusInternalServerError, `{"message":"Internal Server Error"}`},
+		{"/status", http.StatusBadRequest, `{"message":"sql: no rows in result set"}`},
+		{"/unavailable", http.StatusServiceUnavailable, `{"message":"Service Unavailable"}`},
+		{"/group/missing", http.StatusGone, `{"message":"Gone"}`},
+		{"/custom/internal", http.StatusInternalServerError, `{"message":"Internal Server Error"}`},
+		{"/debug/internal", http.StatusInternalServerError, `{"message":"connection refused"}`},
+		{"/debug/missing", http.StatusNotFound, `{"type":"https://example.com/problems/not-found","message":"Not Found","detail":"sql: no rows in result set"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			rec := serve(test.target)
+			assert.Equal(t, test.status, rec.Code)
+			assert.JSONEq(t, test.body, rec.Body.String())
+		})
+	}
+
+	//the headers of a hidden error are kept
+	assert.Equal(t, "30", serve("/unavailable").Header().Get("Retry-After"))
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/mbict/go-commandbus/v2"
 	"github.com/mbict/go-querybus"
 	"github.com/mbict/go-webapp"
+	"github.com/mbict/go-webapp/async"
+	"github.com/mbict/go-webapp/idempotency"
 	"log"
 	"net/http"
 )
@@ -106,6 +108,25 @@ func HandleCreateCommand[T commandbus.Command](gen func(cmd *T) string) http.Han
 	})
 }
 
+func HandleAsyncCommand[T commandbus.Command](jobs *async.Executor) http.HandlerFunc {
+	return webapp.H(func(ctx context.Context, cmd T) (interface{}, error) {
+		//validate before the command is accepted
+		if err := validate.Struct(cmd); err != nil {
+			//validation failed
+			return nil, err
+		}
+
+		//push the command onto the commandBus in the background, the location points to the status of the job
+		id, err := jobs.Submit(ctx, func(ctx context.Context) (any, error) {
+			return nil, commandBus.Handle(ctx, cmd)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return webapp.NewAcceptedResponse(jobs.Location(ctx, id)), nil
+	})
+}
+
 func HandleQuery[T any]() http.HandlerFunc {
 	return webapp.H(func(ctx context.Context, query T) (interface{}, error) {
 		//validate
@@ -154,15 +175,21 @@ func main() {
 
 	r.Get("/res/@id", HandleQuery[QueryExample]())
 
-	//example of a creational command that will return a location header
+	//example of a creational command that will return a location header, retries with the same Idempotency-Key
+	//replay the first response instead of creating another resource
 	r.Post("/res", HandleCreateCommand[CreateCommand](func(cmd *CreateCommand) string {
 		cmd.Id = uuid.New()
 		return "http://localhost/res/" + cmd.Id.String()
-	}))
+	}), idempotency.Middleware(idempotency.NewMemoryStore()))
 
 	//normal commands that only process the request and do not return any information
 	r.Put("/res/@id", HandleCommand[UpdateCommand]())
 
+	//long-running commands are accepted and processed in the background, the status of the job is at /jobs/@id
+	jobs := async.NewExecutor(async.NewMemoryStore(), "/jobs")
+	jobs.Routes(r)
+	r.Put("/async/res/@id", HandleAsyncCommand[UpdateCommand](jobs))
+
 	log.Println(r.ListenAndServe(":8080"))
 }
 
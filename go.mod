@@ -1,29 +1,34 @@
 module github.com/mbict/go-webapp
 
-go 1.18
+go 1.21
 
 require (
 	github.com/go-playground/validator/v10 v10.11.0
-	github.com/goccy/go-json v0.9.7
-	github.com/goccy/go-reflect v1.1.0
+	github.com/goccy/go-json v0.11.1
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/schema v1.2.0
 	github.com/justinas/alice v1.2.0
 	github.com/mbict/go-commandbus/v2 v2.0.0-20200228154118-ac24eb76d1b7
 	github.com/mbict/go-querybus v0.0.0-20220528190455-8fbe8f5f7623
 	github.com/mbict/httprouter v0.0.0-20220523185147-668e097dd194
-	github.com/stretchr/testify v1.7.0
+	github.com/stretchr/testify v1.8.4
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
 	go.uber.org/dig v1.14.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/go-playground/locales v0.14.0 // indirect
 	github.com/go-playground/universal-translator v0.18.0 // indirect
 	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
 	golang.org/x/crypto v0.0.0-20220214200702-86341886e292 // indirect
-	golang.org/x/sys v0.0.0-20220227234510-4e6760a101f9 // indirect
+	golang.org/x/sys v0.17.0 // indirect
 	golang.org/x/text v0.3.7 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -1,13 +1,13 @@
 package webapp
 
 import (
-	"github.com/justinas/alice"
+	"io/fs"
 	"net/http"
 )
 
 type group struct {
-	r          *API
-	middleware alice.Chain
+	r          Router
+	middleware []Middleware
 	prefix     string
 }
 
@@ -36,11 +36,21 @@ func (g *group) Handle(method, path string, handle http.HandlerFunc, mw ...Middl
 }
 
 func (g *group) Handler(method, path string, handle http.Handler, mw ...Middleware) {
-	g.r.Handler(method, g.prefix+path, g.middleware.Append(mc(mw)...).Then(handle))
+	g.r.Handler(method, g.prefix+path, handle, g.chain(mw)...)
 }
 
 func (g *group) Group(path string, mw ...Middleware) Router {
-	return &group{prefix: g.prefix + path, r: g.r, middleware: g.middleware.Append(mc(mw)...)}
+	return &group{prefix: g.prefix + path, r: g.r, middleware: g.chain(mw)}
+}
+
+func (g *group) Static(prefix string, fsys fs.FS, options ...StaticOption) {
+	registerStatic(g, apiOf(g.r), prefix, fsys, options)
+}
+
+// chain returns the group middleware followed by mw, without sharing the backing array between routes.
+func (g *group) chain(mw []Middleware) []Middleware {
+	res := make([]Middleware, 0, len(g.middleware)+len(mw))
+	return append(append(res, g.middleware...), mw...)
 }
 
 //
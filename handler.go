@@ -4,11 +4,14 @@ package webapp
 
 import (
 	"context"
+	"errors"
 	"github.com/mbict/go-webapp/container"
 	"github.com/mbict/go-webapp/decoder"
 	"github.com/mbict/go-webapp/encoding/json"
-	"log"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
 // StatusCoder allows you to customise the HTTP response code.
@@ -37,6 +40,51 @@ type HandlerContext struct {
 	decoderNegotiator NegotiatorBuilder[Decoder]
 	defaultEncoding   string
 	errorHandler      func(error) error
+	errorMappings     []errorMapping
+	debugErrors       bool
+	timeout           time.Duration
+	authorizer        Authorizer
+	logger            *slog.Logger
+	tracerProvider    trace.TracerProvider
+	strictQuery       bool
+	sourceOrder       []string
+	trustedProxies    decoder.TrustedProxies
+	versionSources    []VersionSource
+	defaultVersion    string
+}
+
+func newHandlerContext(options []Option) *HandlerContext {
+	//create the default configurable context
+	handlerCtx := &HandlerContext{
+		container:         container.Default,
+		encoderNegotiator: NewNegotiatorBuilder[Encoder](),
+		decoderNegotiator: NewNegotiatorBuilder[Decoder](),
+		defaultEncoding:   DefaultEncoding,
+		errorHandler: func(err error) error {
+			if _, ok := err.(StatusCoder); ok {
+				return err
+			}
+			return Error(err, http.StatusInternalServerError)
+		},
+	}
+
+	//process the options on top of the global default ones
+	for _, option := range DefaultOptions {
+		option(handlerCtx)
+	}
+
+	for _, option := range options {
+		option(handlerCtx)
+	}
+
+	return handlerCtx
+}
+
+func (ctx *HandlerContext) getLogger() *slog.Logger {
+	if ctx.logger != nil {
+		return ctx.logger
+	}
+	return slog.Default()
 }
 
 func (ctx *HandlerContext) getEncoder(acceptMimetype string) (Encoder, error) {
@@ -52,6 +100,101 @@ func (ctx *HandlerContext) getEncoder(acceptMimetype string) (Encoder, error) {
 	return enc, err
 }
 
+// handleError renders the error with the negotiated encoder and logs it with the request id and the underlying cause.
+func (ctx *HandlerContext) handleError(e error, rw http.ResponseWriter, req *http.Request) {
+	cause := e
+	e = ctx.mapError(e)
+
+	if info := RequestInfoFromContext(req.Context()); info != nil {
+		info.Err = e
+	}
+
+	enc, err := ctx.getEncoder(req.Header.Get("Accept"))
+	if err != nil {
+		//cannot recover from this one
+		panic("cannot determine the request encoder")
+	}
+
+	rw.Header().Add("Content-Type", enc.Mimetype()+"; charset=utf-8")
+
+	if h, ok := e.(Headerer); ok {
+		for k, v := range h.Header() {
+			rw.Header().Add(k, v[0])
+		}
+	}
+
+	if cs, ok := e.(CookieSetter); ok {
+		for _, c := range cs.Cookies() {
+			http.SetCookie(rw, c)
+		}
+	}
+
+	status := http.StatusInternalServerError
+	if sc, ok := e.(StatusCoder); ok {
+		status = sc.StatusCode()
+	}
+	rw.WriteHeader(status)
+
+	ctx.logError(req, status, e, cause)
+
+	if err = enc.Encode(rw, e); err != nil {
+		ctx.getLogger().ErrorContext(req.Context(), "unable to encode error in error handler",
+			slog.String("request_id", RequestIDFromContext(req.Context())),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+func (ctx *HandlerContext) logError(req *http.Request, status int, e error, cause error) {
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	logger := ctx.getLogger()
+	if !logger.Enabled(req.Context(), level) {
+		return
+	}
+
+	//find the root cause
+	for u := errors.Unwrap(cause); u != nil; u = errors.Unwrap(cause) {
+		cause = u
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Int("status", status),
+		slog.String("error", e.Error()),
+	}
+
+	if cause.Error() != e.Error() {
+		attrs = append(attrs, slog.String("cause", cause.Error()))
+	}
+
+	if id := RequestIDFromContext(req.Context()); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+
+	logger.LogAttrs(req.Context(), level, "request failed", attrs...)
+}
+
+// authorize evaluates the required permissions with the handler authorizer, or the one of the API.
+// Without any authorizer access is denied.
+func (ctx *HandlerContext) authorize(c context.Context, permissions []string, request any) error {
+	a := ctx.authorizer
+	if a == nil {
+		if a = authorizerFromContext(c); a == nil {
+			return ErrForbidden
+		}
+	}
+
+	if err := a.Authorize(c, permissions, request); err != nil {
+		return Error(err, http.StatusForbidden)
+	}
+	return nil
+}
+
 func (c *HandlerContext) RegisterEncoder(contentType string, enc Encoder, aliases ...string) {
 	if c.encoderNegotiator == nil {
 		c.encoderNegotiator = NewNegotiatorBuilder[Encoder]()
@@ -70,88 +213,101 @@ func (c *HandlerContext) RegisterDecoder(contentType string, dec Decoder, aliase
 var defaultEncoder = json.NewJsonEncoding()
 
 // H wraps your handler function with the Go generics magic.
+//
+// The options are stacked on the options of the API and groups the handler is registered on, see UseOptions, so
+// a handler inherits the encoders, container and error handler of the API unless overridden. The options that configure
+// the API, e.g. WithTracerProvider, panic as they only apply to New.
 func H[T any, O any](handle Handle[T, O], options ...Option) http.HandlerFunc {
 
-	//create the default configurable context
-	handlerCtx := &HandlerContext{
-		container:         container.Default,
-		encoderNegotiator: NewNegotiatorBuilder[Encoder](),
-		decoderNegotiator: NewNegotiatorBuilder[Decoder](),
-		defaultEncoding:   "application/json",
-		errorHandler: func(err error) error {
-			if _, ok := err.(StatusCoder); ok {
-				return err
-			}
-			return Error(err, http.StatusInternalServerError)
-		},
-	}
+	contexts := newHandlerContexts(options)
+	handlerCtx := contexts.fallback
 
-	//if no options are provided use the global default ones
-	if len(options) == 0 {
-		options = DefaultOptions
+	handleError := func(handlerCtx *HandlerContext, failure Failure, e error, rw http.ResponseWriter, req *http.Request) {
+		setFailure(req, failure)
+		handlerCtx.handleError(e, rw, req)
 	}
 
-	//process options
-	for _, option := range options {
-		option(handlerCtx)
-	}
+	var req T
 
-	//internal handler for rendering errors
-	handleError := func(e error, rw http.ResponseWriter, req *http.Request) {
-		e = handlerCtx.errorHandler(e)
+	argumentDecoder, err := BuildArgumentsBinder(req)
+	if err != nil {
+		panic(err)
+	}
+	args := argumentDecoder.(*decoders)
 
-		enc, err := handlerCtx.getEncoder(req.Header.Get("Accept"))
+	var defaultsDecoder decoder.Decode
+	if hasTag(req, defaultTag) {
+		defaultsDecoder, err = decoder.NewDefaultDecoder(req, defaultTag)
 		if err != nil {
-			//cannot recover from this one
-			panic("cannot determine the request encoder")
+			panic(err)
 		}
+	}
 
-		rw.Header().Add("Content-Type", enc.Mimetype()+"; charset=utf-8")
+	//strict query can also be enabled by the options of the route, so the decoder is always built
+	strictDecoder, strictErr := decoder.NewStrictQueryDecoder(req, queryTag, bindKeysOf(queryTag))
+	if strictErr != nil && handlerCtx.strictQuery {
+		panic(strictErr)
+	}
 
-		if h, ok := e.(Headerer); ok {
-			for k, v := range h.Header() {
-				rw.Header().Add(k, v[0])
+	isEmpty := emptyCheck[O]()
+
+	decode := func(handlerCtx *HandlerContext, req *http.Request, payload *T) error {
+		//reject unknown query parameters
+		if handlerCtx.strictQuery {
+			if strictErr != nil {
+				return strictErr
+			}
+			if err := strictDecoder(req, payload); err != nil {
+				return Error(err, http.StatusBadRequest)
 			}
 		}
 
-		if cs, ok := e.(CookieSetter); ok {
-			for _, c := range cs.Cookies() {
-				http.SetCookie(rw, c)
+		//set defaults
+		if defaultsDecoder != nil {
+			defaultsReq, span := startSpan(req, "decode defaults")
+			err := defaultsDecoder(defaultsReq, payload)
+			endSpan(span, err)
+			if err != nil {
+				return Error(err, http.StatusBadRequest)
 			}
 		}
 
-		if sc, ok := e.(StatusCoder); ok {
-			rw.WriteHeader(sc.StatusCode())
-		} else {
-			rw.WriteHeader(http.StatusInternalServerError)
+		//decode the body.
+		if req.ContentLength > 0 {
+			dec, err := handlerCtx.decoderNegotiator.Get(req.Header.Get("Content-Type"))
+			if err != nil {
+				return err
+			}
+
+			bodyReq, span := startSpan(req, "decode body")
+			err = dec.Decode(bodyReq, payload)
+			endSpan(span, err)
+			if err != nil {
+				return Error(err, http.StatusBadRequest)
+			}
 		}
 
-		if err = enc.Encode(rw, e); err != nil {
-			log.Printf("unable to encode error in error handler %v", err)
+		//decode arguments, last as this should always override previous body decode
+		if err := args.decodeInOrder(req, payload, handlerCtx.sourceOrder); err != nil {
+			return Error(err, http.StatusBadRequest)
 		}
+		return nil
 	}
 
-	var req T
-
-	argumentDecoder, err := BuildArgumentsBinder(req)
-	if err != nil {
-		panic(err)
-	}
+	return authorizing(func(rw http.ResponseWriter, req *http.Request) {
+		handlerCtx := contexts.of(req)
 
-	var defaultsDecoder decoder.Decode
-	if hasTag(req, defaultTag) {
-		defaultsDecoder, err = decoder.NewDefaultDecoder(req, defaultTag)
-		if err != nil {
-			panic(err)
+		//the deadline covers decoding the request, so it can be bound with request:"deadline"
+		timeout, budget := handlerCtx.requestTimeout(req)
+		if timeout > 0 {
+			c, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			req = req.WithContext(c)
 		}
-	}
-
-	isEmpty := makeEmptyCheck(*new(O))
 
-	return func(rw http.ResponseWriter, req *http.Request) {
 		enc, err := handlerCtx.getEncoder(req.Header.Get("Accept"))
 		if err != nil {
-			handleError(err, rw, req)
+			handleError(handlerCtx, DecodeFailure, err, rw, req)
 			return
 		}
 
@@ -160,38 +316,60 @@ func H[T any, O any](handle Handle[T, O], options ...Option) http.HandlerFunc {
 			res     any
 		)
 
-		//set defaults
-		if defaultsDecoder != nil {
-			if err := defaultsDecoder(req, payload); err != nil {
-				handleError(Error(err, http.StatusBadRequest), rw, req)
-				return
-			}
+		//decode the request
+		decodeReq, span := startSpan(req, "decode")
+		err = decode(handlerCtx, decodeReq, payload)
+		endSpan(span, err)
+		if err != nil {
+			handleError(handlerCtx, DecodeFailure, err, rw, req)
+			return
 		}
 
-		//decode the body.
-		if req.ContentLength > 0 {
-			dec, err := handlerCtx.decoderNegotiator.Get(req.Header.Get("Content-Type"))
-			if err != nil {
-				handleError(err, rw, req)
+		//check the permissions declared on the route, unless a wrapping route already did
+		if permissions := RequiredPermissions(req.Context()); len(permissions) > authorizedPermissions(req.Context()) {
+			if err := handlerCtx.authorize(req.Context(), permissions, *payload); err != nil {
+				handleError(handlerCtx, AuthorizationFailure, err, rw, req)
 				return
 			}
+		}
 
-			if err := dec.Decode(req, payload); err != nil {
-				handleError(Error(err, http.StatusBadRequest), rw, req)
-				return
+		//call action handler, a panic is marked on the way up without recovering it
+		completed := false
+		defer func() {
+			if !completed {
+				setFailure(req, PanicFailure)
 			}
+		}()
+
+		handleReq, span := startSpan(req, "handle")
+		timedOut := false
+		if timeout > 0 {
+			res, err, timedOut = handleWithDeadline(handleReq.Context(), handle, *payload, func(recovered any, stack []byte) {
+				handlerCtx.getLogger().ErrorContext(req.Context(), "handler panicked after the deadline",
+					slog.String("request_id", RequestIDFromContext(req.Context())),
+					slog.Any("panic", recovered),
+					slog.String("stack", string(stack)),
+				)
+			})
+		} else {
+			res, err = handle(handleReq.Context(), *payload)
 		}
-
-		//decode arguments, last as this should always override previous body decode
-		if err := argumentDecoder.Decode(req, payload); err != nil {
-			handleError(Error(err, http.StatusBadRequest), rw, req)
+		completed = true
+		endSpan(span, err)
+		if timedOut {
+			e := error(ErrServiceUnavailable)
+			if budget {
+				e = ErrGatewayTimeout
+			}
+			handleError(handlerCtx, TimeoutFailure, e, rw, req)
 			return
 		}
-
-		//call action handler
-		res, err = handle(req.Context(), *payload)
 		if err != nil {
-			handleError(err, rw, req)
+			//a client that went away is not a failure of the server
+			if errors.Is(req.Context().Err(), context.Canceled) {
+				err = errClientClosedRequest
+			}
+			handleError(handlerCtx, HandlerFailure, err, rw, req)
 			return
 		}
 
@@ -214,9 +392,12 @@ func H[T any, O any](handle Handle[T, O], options ...Option) http.HandlerFunc {
 		}
 
 		if false == isEmpty(res) {
-			if err = enc.Encode(rw, res); err != nil {
-				handleError(Error(err, http.StatusInternalServerError), rw, req)
+			_, span := startSpan(req, "encode")
+			err = enc.Encode(rw, res)
+			endSpan(span, err)
+			if err != nil {
+				handleError(handlerCtx, EncodeFailure, Error(err, http.StatusInternalServerError), rw, req)
 			}
 		}
-	}
+	}).ServeHTTP
 }
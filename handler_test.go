@@ -1 +1,90 @@
 package webapp
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type tenantRequest struct {
+	Tenant string `header:"X-Tenant,required"`
+	Limit  int    `query:"limit"`
+}
+
+func TestRequiredParameter(t *testing.T) {
+	api := New(nil)
+	api.Get("/res", H(func(ctx context.Context, req tenantRequest) (string, error) {
+		return req.Tenant, nil
+	}))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/res", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"message":"missing required header \"X-Tenant\"","source":"header","key":"X-Tenant"}`, rec.Body.String())
+}
+
+func TestStrictQuery(t *testing.T) {
+	api := New(nil)
+	api.Get("/res", H(func(ctx context.Context, req tenantRequest) (string, error) {
+		return req.Tenant, nil
+	}, DefaultOptions.Add(StrictQuery())...))
+
+	req := httptest.NewRequest(http.MethodGet, "/res?limit=1&offset=2", nil)
+	req.Header.Set("X-Tenant", "acme")
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"message":"unknown query parameters offset, accepted are limit","source":"query","unknown":["offset"],"accepted":["limit"]}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/res?limit=1", nil)
+	req.Header.Set("X-Tenant", "acme")
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestStrictQueryBind(t *testing.T) {
+	api := New(nil)
+	api.Get("/res", H(func(ctx context.Context, req struct {
+		Key   string `bind:"header:X-Api-Key|query:api_key|query:key"`
+		Limit int    `query:"limit"`
+	}) (string, error) {
+		return req.Key, nil
+	}), UseOptions(StrictQuery()))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/res?key=abc&limit=1", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `"abc"`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/res?api_key=abc&X-Api-Key=abc", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"message":"unknown query parameters X-Api-Key, accepted are api_key, key, limit","source":"query","unknown":["X-Api-Key"],"accepted":["api_key","key","limit"]}`, rec.Body.String())
+}
+
+func TestStrictQueryUnsupportedType(t *testing.T) {
+	api := New(nil)
+	api.Get("/res", H(func(ctx context.Context, req string) (string, error) {
+		return req, nil
+	}), UseOptions(StrictQuery()))
+
+	rec := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/res", nil))
+	})
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	assert.Panics(t, func() {
+		H(func(ctx context.Context, req string) (string, error) {
+			return req, nil
+		}, StrictQuery())
+	})
+}
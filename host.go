@@ -0,0 +1,136 @@
+package webapp
+
+import (
+	"context"
+	"github.com/mbict/go-webapp/decoder"
+	"github.com/mbict/httprouter"
+	"io/fs"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// hostRouter routes the requests of a host pattern, api.example.com or @tenant.example.com.
+type hostRouter struct {
+	pattern string
+	labels  []string
+	router  *httprouter.Router
+}
+
+// match matches the host, without port, against the labels of the pattern, a @name label matches any label.
+func (h *hostRouter) match(host string) (decoder.ParamsGetter, bool) {
+	var params decoder.ParamsGetter
+	for i, label := range h.labels {
+		var value string
+		value, host, _ = strings.Cut(host, ".")
+
+		//the host has fewer or more labels than the pattern
+		if value == "" || (i == len(h.labels)-1) != (host == "") {
+			return nil, false
+		}
+
+		if name, ok := strings.CutPrefix(label, "@"); ok {
+			params = append(params, httprouter.Param{Key: name, Value: value})
+		} else if !strings.EqualFold(label, value) {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// Host returns the router for the requests of the host, @name labels are bound with the host tag.
+// Requests for a host without a matching pattern are routed by the API itself, exact hosts take precedence over patterns.
+//
+//	tenant := api.Host("@tenant.example.com")
+//	tenant.Get("/users", H(listUsers)) // with a field Tenant string `host:"tenant"`
+func (r *API) Host(pattern string) Router {
+	r.checkModifiable()
+
+	for _, h := range r.hosts {
+		if h.pattern == pattern {
+			return &host{r: r, h: h}
+		}
+	}
+
+	h := &hostRouter{
+		pattern: pattern,
+		labels:  strings.Split(strings.ToLower(pattern), "."),
+		router:  httprouter.New(),
+	}
+
+	//exact hosts before patterns, in order of registration
+	i := len(r.hosts)
+	if !strings.Contains(pattern, "@") {
+		i = 0
+		for i < len(r.hosts) && !strings.Contains(r.hosts[i].pattern, "@") {
+			i++
+		}
+	}
+	r.hosts = slices.Insert(r.hosts, i, h)
+
+	return &host{r: r, h: h}
+}
+
+// routeHost serves the request with the router of the matching host, or the router of the API.
+func (r *API) routeHost(rw http.ResponseWriter, req *http.Request) {
+	name := req.Host
+	if h, _, err := net.SplitHostPort(name); err == nil {
+		name = h
+	}
+	name = strings.TrimSuffix(name, ".")
+
+	for _, h := range r.hosts {
+		if params, ok := h.match(name); ok {
+			if len(params) > 0 {
+				req = req.WithContext(context.WithValue(req.Context(), decoder.HostParamsKey, params))
+			}
+			h.router.ServeHTTP(rw, req)
+			return
+		}
+	}
+
+	r.router.ServeHTTP(rw, req)
+}
+
+// host is the Router of a host pattern.
+type host struct {
+	r *API
+	h *hostRouter
+}
+
+func (h *host) Get(path string, handle http.HandlerFunc, mw ...Middleware) {
+	h.Handler(http.MethodGet, path, handle, mw...)
+}
+
+func (h *host) Post(path string, handle http.HandlerFunc, mw ...Middleware) {
+	h.Handler(http.MethodPost, path, handle, mw...)
+}
+
+func (h *host) Put(path string, handle http.HandlerFunc, mw ...Middleware) {
+	h.Handler(http.MethodPut, path, handle, mw...)
+}
+
+func (h *host) Patch(path string, handle http.HandlerFunc, mw ...Middleware) {
+	h.Handler(http.MethodPatch, path, handle, mw...)
+}
+
+func (h *host) Delete(path string, handle http.HandlerFunc, mw ...Middleware) {
+	h.Handler(http.MethodDelete, path, handle, mw...)
+}
+
+func (h *host) Handle(method, path string, handle http.HandlerFunc, mw ...Middleware) {
+	h.Handler(method, path, handle, mw...)
+}
+
+func (h *host) Handler(method, path string, handle http.Handler, mw ...Middleware) {
+	h.r.handle(h.h.router, &Route{Method: method, Host: h.h.pattern, Path: path}, handle, mw)
+}
+
+func (h *host) Group(path string, mw ...Middleware) Router {
+	return &group{prefix: path, r: h, middleware: mw}
+}
+
+func (h *host) Static(prefix string, fsys fs.FS, options ...StaticOption) {
+	registerStatic(h, h.r, prefix, fsys, options)
+}
@@ -0,0 +1,43 @@
+package webapp
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostRouting(t *testing.T) {
+	type tenantRequest struct {
+		Tenant string `host:"tenant"`
+		ID     string `path:"id"`
+	}
+
+	api := New(nil)
+	api.Get("/", func(rw http.ResponseWriter, req *http.Request) { rw.Write([]byte("default")) })
+	api.Host("api.example.com").Get("/", func(rw http.ResponseWriter, req *http.Request) { rw.Write([]byte("api")) })
+	api.Host("@tenant.example.com").Group("/users").Get("/@id", H(func(ctx context.Context, req tenantRequest) (string, error) {
+		return req.Tenant + " " + req.ID, nil
+	}))
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	assert.Equal(t, "default", serve(http.MethodGet, "http://other.com/").Body.String())
+	assert.Equal(t, "api", serve(http.MethodGet, "http://API.example.com:8080/").Body.String())
+	assert.Equal(t, "\"acme 1\"\n", serve(http.MethodGet, "http://acme.example.com/users/1").Body.String())
+
+	//a matched host does not fall back to the routes of the API
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "http://acme.example.com/").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "http://acme.example.com/users/1").Code)
+
+	//the pattern has to match all labels
+	assert.Equal(t, "default", serve(http.MethodGet, "http://a.b.example.com/").Body.String())
+
+	assert.Equal(t, "@tenant.example.com", api.Routes()[2].Host)
+	assert.Equal(t, "/users/@id", api.Routes()[2].Path)
+}
@@ -0,0 +1,299 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/mbict/go-webapp"
+	"github.com/mbict/go-webapp/auth"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type createRequest struct {
+	Name string `json:"name"`
+}
+
+func TestMiddleware(t *testing.T) {
+	var created atomic.Int32
+
+	api := webapp.New(nil)
+	mw := Middleware(NewMemoryStore())
+	api.Post("/res", webapp.H(func(ctx context.Context, req createRequest) (webapp.CreatedResponse, error) {
+		created.Add(1)
+		return webapp.NewCreatedResponse("/res/" + req.Name), nil
+	}), mw)
+	api.Post("/other", webapp.H(func(ctx context.Context, req createRequest) (webapp.CreatedResponse, error) {
+		created.Add(1)
+		return webapp.NewCreatedResponse("/other/" + req.Name), nil
+	}), mw)
+
+	serve := func(target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set(Header, key)
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	rec := serve("/res", "abc", `{"name":"first"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/res/first", rec.Header().Get("Location"))
+	assert.Empty(t, rec.Header().Get(ReplayedHeader))
+
+	//the retry is replayed
+	rec = serve("/res", "abc", `{"name":"first"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/res/first", rec.Header().Get("Location"))
+	assert.Equal(t, "true", rec.Header().Get(ReplayedHeader))
+	assert.Equal(t, int32(1), created.Load())
+
+	//the same key with another payload
+	rec = serve("/res", "abc", `{"name":"second"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	//keys are scoped to the route
+	rec = serve("/other", "abc", `{"name":"first"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, int32(2), created.Load())
+
+	//requests without a key are not idempotent
+	serve("/res", "", `{"name":"first"}`)
+	serve("/res", "", `{"name":"first"}`)
+	assert.Equal(t, int32(4), created.Load())
+}
+
+func TestMiddlewareConcurrentRequest(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	api := webapp.New(nil)
+	api.Post("/res", webapp.H(func(ctx context.Context, req createRequest) (webapp.CreatedResponse, error) {
+		close(started)
+		<-release
+		return webapp.NewCreatedResponse("/res/1"), nil
+	}), Middleware(NewMemoryStore()))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/res", strings.NewReader(`{"name":"first"}`))
+		req.Header.Set(Header, "abc")
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve() }()
+	<-started
+
+	assert.Equal(t, http.StatusConflict, serve().Code)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+}
+
+func TestMiddlewareServerError(t *testing.T) {
+	var calls atomic.Int32
+
+	api := webapp.New(nil)
+	api.Post("/res", webapp.H(func(ctx context.Context, req createRequest) (webapp.CreatedResponse, error) {
+		if calls.Add(1) == 1 {
+			return webapp.CreatedResponse{}, errors.New("database is down")
+		}
+		return webapp.NewCreatedResponse("/res/1"), nil
+	}), Middleware(NewMemoryStore()))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/res", strings.NewReader(`{"name":"first"}`))
+		req.Header.Set(Header, "abc")
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, serve().Code)
+	assert.Equal(t, http.StatusCreated, serve().Code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestMemoryStoreExpires(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, reserved, _ := store.Reserve(ctx, "key", "fp", "a", time.Minute)
+	assert.True(t, reserved)
+
+	assert.NoError(t, store.Complete(ctx, "key", "a", &Response{Status: http.StatusCreated}, time.Hour))
+
+	now = now.Add(30 * time.Minute)
+	record, reserved, _ := store.Reserve(ctx, "key", "fp", "b", time.Minute)
+	assert.False(t, reserved)
+	assert.Equal(t, http.StatusCreated, record.Response.Status)
+
+	now = now.Add(time.Hour)
+	_, reserved, _ = store.Reserve(ctx, "key", "fp", "c", time.Minute)
+	assert.True(t, reserved)
+}
+
+func TestMemoryStoreOwner(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, reserved, _ := store.Reserve(ctx, "key", "fp", "slow", time.Minute)
+	assert.True(t, reserved)
+
+	//the reservation of the slow request expired and is taken over by a retry
+	now = now.Add(2 * time.Minute)
+	_, reserved, _ = store.Reserve(ctx, "key", "fp", "retry", time.Minute)
+	assert.True(t, reserved)
+
+	//the slow request no longer owns the key
+	assert.NoError(t, store.Release(ctx, "key", "slow"))
+	assert.NoError(t, store.Complete(ctx, "key", "slow", &Response{Status: http.StatusCreated}, time.Hour))
+
+	record, reserved, _ := store.Reserve(ctx, "key", "fp", "other", time.Minute)
+	assert.False(t, reserved)
+	assert.Nil(t, record.Response)
+
+	assert.NoError(t, store.Complete(ctx, "key", "retry", &Response{Status: http.StatusAccepted}, time.Hour))
+	record, _, _ = store.Reserve(ctx, "key", "fp", "other", time.Minute)
+	assert.Equal(t, http.StatusAccepted, record.Response.Status)
+}
+
+func TestMiddlewareTransientStatus(t *testing.T) {
+	var calls atomic.Int32
+
+	api := webapp.New(nil)
+	api.Post("/res", webapp.H(func(ctx context.Context, req createRequest) (webapp.CreatedResponse, error) {
+		switch calls.Add(1) {
+		case 1:
+			return webapp.CreatedResponse{}, webapp.ErrTooManyRequests
+		case 2:
+			return webapp.CreatedResponse{}, webapp.ErrConflict
+		}
+		return webapp.NewCreatedResponse("/res/1"), nil
+	}), Middleware(NewMemoryStore()))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/res", strings.NewReader(`{"name":"first"}`))
+		req.Header.Set(Header, "abc")
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	//responses depending on the moment of the request are not replayed
+	assert.Equal(t, http.StatusTooManyRequests, serve().Code)
+	assert.Equal(t, http.StatusConflict, serve().Code)
+	assert.Equal(t, http.StatusCreated, serve().Code)
+	assert.Equal(t, "true", serve().Header().Get(ReplayedHeader))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestMiddlewareReplayHeaders(t *testing.T) {
+	var requests atomic.Int32
+
+	api := webapp.New(nil)
+	api.Use(webapp.RequestID())
+	api.Post("/res", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("X-Rate-Limit-Remaining", strconv.Itoa(int(10-requests.Add(1))))
+		rw.Header().Set("Location", "/res/1")
+		rw.WriteHeader(http.StatusCreated)
+	}, Middleware(NewMemoryStore()))
+
+	serve := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/res", strings.NewReader(`{"name":"first"}`))
+		req.Header.Set(Header, "abc")
+		req.Header.Set(webapp.RequestIDHeader, id)
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	serve("first")
+	rec := serve("retry")
+
+	//only the allowed headers are replayed, the retry keeps its own
+	assert.Equal(t, "true", rec.Header().Get(ReplayedHeader))
+	assert.Equal(t, "/res/1", rec.Header().Get("Location"))
+	assert.Equal(t, "retry", rec.Header().Get(webapp.RequestIDHeader))
+	assert.Empty(t, rec.Header().Get("X-Rate-Limit-Remaining"))
+}
+
+func TestMiddlewarePrincipal(t *testing.T) {
+	var created atomic.Int32
+
+	api := webapp.New(nil)
+	api.Post("/res", webapp.H(func(ctx context.Context, req createRequest) (webapp.CreatedResponse, error) {
+		created.Add(1)
+		return webapp.NewCreatedResponse("/res/" + req.Name), nil
+	}), Middleware(NewMemoryStore()))
+
+	serve := func(subject string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/res", strings.NewReader(`{"name":"first"}`))
+		req.Header.Set(Header, "abc")
+		req = req.WithContext(auth.NewContext(req.Context(), &auth.Principal{Subject: subject}))
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	assert.Empty(t, serve("alice").Header().Get(ReplayedHeader))
+	assert.Equal(t, "true", serve("alice").Header().Get(ReplayedHeader))
+
+	//another principal with the same key is a different request
+	assert.Empty(t, serve("bob").Header().Get(ReplayedHeader))
+	assert.Equal(t, int32(2), created.Load())
+}
+
+func TestMiddlewareMaxBodySize(t *testing.T) {
+	api := webapp.New(nil)
+	api.Post("/res", webapp.H(func(ctx context.Context, req createRequest) (webapp.CreatedResponse, error) {
+		return webapp.NewCreatedResponse("/res/" + req.Name), nil
+	}), Middleware(NewMemoryStore(), WithMaxBodySize(16)))
+
+	req := httptest.NewRequest(http.MethodPost, "/res", strings.NewReader(`{"name":"a name that is too long"}`))
+	req.Header.Set(Header, "abc")
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+// failingStore fails to complete and release keys.
+type failingStore struct {
+	*MemoryStore
+}
+
+func (s failingStore) Complete(ctx context.Context, key string, token string, res *Response, ttl time.Duration) error {
+	return errors.New("store is down")
+}
+
+func TestMiddlewareStoreError(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	api := webapp.New(nil)
+	api.Post("/res", webapp.H(func(ctx context.Context, req createRequest) (webapp.CreatedResponse, error) {
+		return webapp.NewCreatedResponse("/res/" + req.Name), nil
+	}), Middleware(failingStore{NewMemoryStore()}, WithLogger(slog.New(slog.NewJSONHandler(buf, nil)))))
+
+	req := httptest.NewRequest(http.MethodPost, "/res", strings.NewReader(`{"name":"first"}`))
+	req.Header.Set(Header, "abc")
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, buf.String(), `"msg":"unable to complete idempotency key"`)
+	assert.Contains(t, buf.String(), `"error":"store is down"`)
+}
@@ -0,0 +1,274 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/mbict/go-webapp"
+	"github.com/mbict/go-webapp/auth"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Header is the request header carrying the idempotency key.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader is set on responses replayed from the store.
+const ReplayedHeader = "Idempotent-Replayed"
+
+type config struct {
+	methods     []string
+	ttl         time.Duration
+	lockTimeout time.Duration
+	maxBodySize int64
+	headers     []string
+	logger      *slog.Logger
+}
+
+type Option func(*config)
+
+// WithMethods sets the methods that honour the idempotency key, by default POST and PATCH.
+func WithMethods(methods ...string) Option {
+	return func(c *config) {
+		c.methods = methods
+	}
+}
+
+// WithTTL sets how long a response is replayed, by default 24 hours.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// WithLockTimeout sets how long a key is reserved for a request in progress, by default a minute. A request that
+// crashes the process without releasing its key blocks retries until then.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.lockTimeout = timeout
+	}
+}
+
+// WithMaxBodySize sets the maximum size of the request body that is read to fingerprint the payload, by default 1 MiB.
+// Larger requests are rejected with 413 Request Entity Too Large.
+func WithMaxBodySize(size int64) Option {
+	return func(c *config) {
+		c.maxBodySize = size
+	}
+}
+
+// WithReplayHeaders sets the response headers that are stored and replayed, by default the headers describing the
+// content and Location. Other headers, e.g. X-Request-ID or rate limit headers, are set by the retry itself.
+func WithReplayHeaders(headers ...string) Option {
+	return func(c *config) {
+		c.headers = headers
+	}
+}
+
+// WithLogger sets the logger for the errors of the store that cannot be returned, defaults to the slog default logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// Middleware returns middleware that honours the Idempotency-Key header. The response of the first request with a key
+// is stored per key, principal and route, and replayed for retries. A retry while the first request is in progress is
+// rejected with 409 Conflict, a retry with a different payload with 422 Unprocessable Entity.
+// Only successful responses and client errors that a retry would get again are stored, other responses release the
+// key so the request can be retried.
+func Middleware(store Store, options ...Option) webapp.Middleware {
+	cfg := &config{
+		methods:     []string{http.MethodPost, http.MethodPatch},
+		ttl:         24 * time.Hour,
+		lockTimeout: time.Minute,
+		maxBodySize: 1 << 20,
+		headers:     []string{"Content-Type", "Content-Language", "Content-Location", "Location", "ETag", "Last-Modified"},
+		logger:      slog.Default(),
+	}
+	for _, option := range options {
+		option(cfg)
+	}
+	headers := make([]string, len(cfg.headers))
+	for i, h := range cfg.headers {
+		headers[i] = http.CanonicalHeaderKey(h)
+	}
+
+	errorHandler := webapp.ErrorHandler()
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			key := req.Header.Get(Header)
+			if key == "" || !slices.Contains(cfg.methods, req.Method) {
+				next(rw, req)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, cfg.maxBodySize))
+			if err != nil {
+				status := http.StatusBadRequest
+				if mbe := (*http.MaxBytesError)(nil); errors.As(err, &mbe) {
+					status = http.StatusRequestEntityTooLarge
+				}
+				errorHandler(webapp.Error(err, status))(rw, req)
+				return
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+			key = scope(req, key)
+
+			token := uuid.NewString()
+			record, reserved, err := store.Reserve(req.Context(), key, fingerprint, token, cfg.lockTimeout)
+			if err != nil {
+				errorHandler(webapp.Error(err, http.StatusInternalServerError))(rw, req)
+				return
+			}
+
+			if !reserved {
+				switch {
+				case record.Fingerprint != fingerprint:
+					errorHandler(webapp.ErrUnprocessableEntity)(rw, req)
+				case record.Response == nil:
+					errorHandler(webapp.ErrConflict)(rw, req)
+				default:
+					replay(rw, record.Response)
+				}
+				return
+			}
+
+			//the key is completed or released after the response, also when the client went away
+			ctx := context.WithoutCancel(req.Context())
+
+			rec := &recorder{ResponseWriter: rw}
+			completed := false
+			defer func() {
+				//a failed request releases the key, so it can be retried
+				if !completed || !storable(rec.StatusCode()) {
+					if err := store.Release(ctx, key, token); err != nil {
+						cfg.logError(ctx, "unable to release idempotency key", err)
+					}
+				}
+			}()
+
+			next(rec, req)
+			completed = true
+
+			if storable(rec.StatusCode()) {
+				if err := store.Complete(ctx, key, token, rec.response(headers), cfg.ttl); err != nil {
+					cfg.logError(ctx, "unable to complete idempotency key", err)
+				}
+			}
+		}
+	}
+}
+
+// storable tells if the response of the status is stored, a retry gets the same response of a successful request or
+// of a client error, except for the ones that depend on the moment of the request.
+func storable(status int) bool {
+	switch {
+	case status >= 200 && status < 300:
+		return true
+	case status < 400 || status >= 500:
+		return false
+	}
+
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooEarly, http.StatusTooManyRequests:
+		return false
+	}
+	return true
+}
+
+func (c *config) logError(ctx context.Context, msg string, err error) {
+	c.logger.ErrorContext(ctx, msg,
+		slog.String("request_id", webapp.RequestIDFromContext(ctx)),
+		slog.String("error", err.Error()),
+	)
+}
+
+// scope scopes the key to the principal and the route of the request.
+func scope(req *http.Request, key string) string {
+	var subject string
+	if p, ok := auth.FromContext(req.Context()); ok {
+		subject = p.Subject
+	}
+
+	route := req.URL.Path
+	if info := webapp.RequestInfoFromContext(req.Context()); info != nil && info.Route != "" {
+		route = info.Route
+	}
+
+	return strconv.Quote(subject) + ":" + req.Method + ":" + route + ":" + key
+}
+
+func replay(rw http.ResponseWriter, res *Response) {
+	h := rw.Header()
+	for k, v := range res.Header {
+		h[k] = v
+	}
+	h.Set(ReplayedHeader, "true")
+	rw.WriteHeader(res.Status)
+	rw.Write(res.Body)
+}
+
+// recorder records the response while writing it.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+		r.header = r.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// StatusCode returns the recorded status, a handler that never writes responds with 200 OK.
+func (r *recorder) StatusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// response returns the recorded response with the allowed headers only.
+func (r *recorder) response(allowed []string) *Response {
+	written := r.header
+	if written == nil {
+		written = r.Header()
+	}
+
+	header := http.Header{}
+	for _, k := range allowed {
+		if v, ok := written[k]; ok {
+			header[k] = slices.Clone(v)
+		}
+	}
+	return &Response{Status: r.StatusCode(), Header: header, Body: r.body.Bytes()}
+}
@@ -0,0 +1,116 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response is a stored response, replayed for retries of the request.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record is the state of an idempotency key.
+type Record struct {
+	// Fingerprint of the payload of the first request with the key.
+	Fingerprint string
+
+	// Response of the first request, nil while it is in progress.
+	Response *Response
+}
+
+// Store keeps the idempotency records. Implementations must reserve keys atomically, so a distributed store can be
+// used to share keys between processes.
+//
+// A reservation is owned by the token of the request that made it. Complete and Release must ignore a token that does
+// not own the key, as the reservation of a request that overran ttl may be taken over by a retry.
+type Store interface {
+	// Reserve reserves the key for a request with the fingerprint for at most ttl, owned by the token. When the key is
+	// already known, false is returned with its record.
+	Reserve(ctx context.Context, key string, fingerprint string, token string, ttl time.Duration) (Record, bool, error)
+
+	// Complete stores the response of the key reserved with the token, the record may be dropped after ttl.
+	Complete(ctx context.Context, key string, token string, res *Response, ttl time.Duration) error
+
+	// Release drops the reservation of the key made with the token, so the request can be retried.
+	Release(ctx context.Context, key string, token string) error
+}
+
+type memoryEntry struct {
+	record  Record
+	token   string
+	expires time.Time
+}
+
+// MemoryStore is an in-memory Store for a single process.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Reserve(_ context.Context, key string, fingerprint string, token string, ttl time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if e, ok := s.entries[key]; ok && !now.After(e.expires) {
+		return e.record, false, nil
+	}
+
+	s.entries[key] = &memoryEntry{
+		record:  Record{Fingerprint: fingerprint},
+		token:   token,
+		expires: now.Add(ttl),
+	}
+	return Record{}, true, nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, key string, token string, res *Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.token == token {
+		e.record.Response = res
+		e.expires = s.now().Add(ttl)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.token == token {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// sweep removes expired entries, at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(time.Minute)
+
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
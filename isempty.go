@@ -2,9 +2,29 @@ package webapp
 
 import (
 	"reflect"
+	"sync"
 	"unsafe"
 )
 
+var emptyChecks sync.Map
+
+// RegisterEmptyCheck registers a specialised empty check for the response type O, usually generated by webapp-gen.
+// Handlers returning O use the check instead of reflection, so register it before the handlers are created.
+func RegisterEmptyCheck[O any](fn func(O) bool) {
+	emptyChecks.Store(reflect.TypeOf((*O)(nil)).Elem(), func(v any) bool {
+		o, _ := v.(O)
+		return fn(o)
+	})
+}
+
+// emptyCheck returns the registered empty check of O, falling back to reflection.
+func emptyCheck[O any]() func(any) bool {
+	if fn, ok := emptyChecks.Load(reflect.TypeOf((*O)(nil)).Elem()); ok {
+		return fn.(func(any) bool)
+	}
+	return makeEmptyCheck(*new(O))
+}
+
 func makeEmptyCheck(zero any) func(any) bool {
 
 	//if implements empty interface we assume empty
@@ -34,8 +54,7 @@ func makeEmptyCheck(zero any) func(any) bool {
 	case reflect.Slice:
 		// Return true for and nil slice.
 		return func(v any) bool {
-			header := (*reflect.SliceHeader)((*emptyInterface)(unsafe.Pointer(&v)).ptr)
-			return header.Data == 0
+			return reflect.ValueOf(v).IsNil()
 		}
 	default:
 		// Return false for all others.
@@ -113,3 +113,20 @@ func TestNilCheck(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisteredEmptyCheck(t *testing.T) {
+	type response struct {
+		Items []string
+	}
+
+	RegisterEmptyCheck(func(v *response) bool { return v == nil || len(v.Items) == 0 })
+
+	isEmpty := emptyCheck[*response]()
+	if !isEmpty(&response{}) {
+		t.Errorf("registered check should be used")
+	}
+
+	if emptyCheck[response]()(response{}) {
+		t.Errorf("unregistered type should fall back to reflection")
+	}
+}
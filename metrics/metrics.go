@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"github.com/mbict/go-webapp"
+	"github.com/mbict/go-webapp/internal"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultBuckets are the latency histogram buckets in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets are the response size histogram buckets in bytes.
+var DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+
+type Option func(*Metrics)
+
+// WithNamespace prefixes all metric names with the namespace.
+func WithNamespace(namespace string) Option {
+	return func(m *Metrics) {
+		m.namespace = namespace + "_"
+	}
+}
+
+// WithBuckets sets the latency histogram buckets in seconds.
+func WithBuckets(buckets ...float64) Option {
+	return func(m *Metrics) {
+		m.buckets = buckets
+	}
+}
+
+// WithSizeBuckets sets the response size histogram buckets in bytes.
+func WithSizeBuckets(buckets ...float64) Option {
+	return func(m *Metrics) {
+		m.sizeBuckets = buckets
+	}
+}
+
+// Metrics records per route request metrics and exposes them in the Prometheus text exposition format.
+// Routes are labelled by their pattern, e.g. /res/@id, so path parameters do not create new series. Responses are
+// labelled by their status class, e.g. 2xx, and methods outside the standard ones by OTHER.
+type Metrics struct {
+	namespace   string
+	buckets     []float64
+	sizeBuckets []float64
+
+	requests     *family
+	duration     *family
+	size         *family
+	inFlight     *family
+	decodeErrors *family
+	encodeErrors *family
+	panics       *family
+
+	registry registry
+}
+
+func New(options ...Option) *Metrics {
+	m := &Metrics{
+		buckets:     DefaultBuckets,
+		sizeBuckets: DefaultSizeBuckets,
+	}
+
+	for _, option := range options {
+		option(m)
+	}
+
+	labels := []string{"method", "route", "status_class"}
+	routeLabels := []string{"method", "route"}
+
+	m.requests = newFamily(m.namespace+"http_requests_total", "Total number of HTTP requests.", counterKind, labels, nil)
+	m.duration = newFamily(m.namespace+"http_request_duration_seconds", "Latency of HTTP requests in seconds.", histogramKind, labels, m.buckets)
+	m.size = newFamily(m.namespace+"http_response_size_bytes", "Size of HTTP responses in bytes.", histogramKind, labels, m.sizeBuckets)
+	m.inFlight = newFamily(m.namespace+"http_requests_in_flight", "Number of HTTP requests being served.", gaugeKind, []string{"method"}, nil)
+	m.decodeErrors = newFamily(m.namespace+"http_decode_errors_total", "Requests that could not be decoded.", counterKind, routeLabels, nil)
+	m.encodeErrors = newFamily(m.namespace+"http_encode_errors_total", "Responses that could not be encoded.", counterKind, routeLabels, nil)
+	m.panics = newFamily(m.namespace+"http_panics_total", "Handlers that panicked.", counterKind, routeLabels, nil)
+
+	m.registry = registry{m.requests, m.duration, m.size, m.inFlight, m.decodeErrors, m.encodeErrors, m.panics}
+
+	return m
+}
+
+// Middleware records the metrics of every request passing through it. Use it as global middleware to
+// cover all routes, including the not found ones.
+func (m *Metrics) Middleware() webapp.Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			info, req := webapp.WithRequestInfo(req)
+			w := internal.NewResponseWriter(rw)
+
+			method := methodLabel(req.Method)
+			m.inFlight.add(1, method)
+
+			//deferred, so panics are recorded on their way up
+			defer func() {
+				m.inFlight.add(-1, method)
+
+				route := info.Route
+				if route == "" {
+					route = "unmatched"
+				}
+
+				status := w.StatusCode()
+				if info.Failure == webapp.PanicFailure {
+					status = http.StatusInternalServerError
+				}
+				class := strconv.Itoa(status/100) + "xx"
+
+				m.requests.add(1, method, route, class)
+				m.duration.observe(time.Since(start).Seconds(), method, route, class)
+				m.size.observe(float64(w.Bytes), method, route, class)
+
+				switch info.Failure {
+				case webapp.DecodeFailure:
+					m.decodeErrors.add(1, method, route)
+				case webapp.EncodeFailure:
+					m.encodeErrors.add(1, method, route)
+				case webapp.PanicFailure:
+					m.panics.add(1, method, route)
+				}
+			}()
+
+			next(w, req)
+		}
+	}
+}
+
+// methodLabel limits the method label to the standard methods, so clients cannot create new series.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// ServeHTTP exposes the metrics, register it on the endpoint of your choice:
+//
+//	api.Handler(http.MethodGet, "/metrics", m)
+func (m *Metrics) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.registry.writeTo(rw)
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"github.com/mbict/go-webapp"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type resRequest struct {
+	Id    string `path:"id"`
+	Limit int    `query:"limit"`
+}
+
+func TestMetrics(t *testing.T) {
+	m := New(WithNamespace("app"), WithBuckets(0.1, 1), WithSizeBuckets(10, 1000))
+
+	api := webapp.New(nil)
+	api.Use(m.Middleware())
+	api.Handler(http.MethodGet, "/metrics", m)
+	api.Get("/res/@id", webapp.H(func(ctx context.Context, req resRequest) (string, error) {
+		return req.Id, nil
+	}))
+	api.Get("/encode/@id", webapp.H(func(ctx context.Context, req resRequest) (any, error) {
+		return make(chan int), nil
+	}))
+	api.Get("/panic/@id", webapp.H(func(ctx context.Context, req resRequest) (string, error) {
+		panic("boom")
+	}))
+
+	h := api.RequestHander()
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	serve("/res/1")
+	serve("/res/2")
+	serve("/res/3?limit=abc")
+	serve("/encode/1")
+	serve("/unknown")
+	assert.Panics(t, func() { serve("/panic/1") })
+
+	rec := serve("/metrics")
+	body := rec.Body.String()
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, body, "# TYPE app_http_requests_total counter\n")
+	assert.Contains(t, body, `app_http_requests_total{method="GET",route="/res/@id",status_class="2xx"} 2`+"\n")
+	assert.Contains(t, body, `app_http_requests_total{method="GET",route="/res/@id",status_class="4xx"} 1`+"\n")
+	assert.Contains(t, body, `app_http_requests_total{method="GET",route="unmatched",status_class="4xx"} 1`+"\n")
+	assert.Contains(t, body, `app_http_requests_total{method="GET",route="/panic/@id",status_class="5xx"} 1`+"\n")
+	assert.Contains(t, body, "# TYPE app_http_request_duration_seconds histogram\n")
+	assert.Contains(t, body, `app_http_request_duration_seconds_bucket{method="GET",route="/res/@id",status_class="2xx",le="+Inf"} 2`+"\n")
+	assert.Contains(t, body, `app_http_request_duration_seconds_count{method="GET",route="/res/@id",status_class="2xx"} 2`+"\n")
+	assert.Contains(t, body, `app_http_response_size_bytes_bucket{method="GET",route="/res/@id",status_class="2xx",le="10"} 2`+"\n")
+	assert.Contains(t, body, `app_http_response_size_bytes_sum{method="GET",route="/res/@id",status_class="2xx"} 8`+"\n")
+	assert.Contains(t, body, `app_http_requests_in_flight{method="GET"} 1`+"\n")
+	assert.Contains(t, body, `app_http_decode_errors_total{method="GET",route="/res/@id"} 1`+"\n")
+	assert.Contains(t, body, `app_http_encode_errors_total{method="GET",route="/encode/@id"} 1`+"\n")
+	assert.Contains(t, body, `app_http_panics_total{method="GET",route="/panic/@id"} 1`+"\n")
+}
+
+func TestLabelEscaping(t *testing.T) {
+	m := New()
+	m.requests.add(1, "GET", "/a\"b\\c\nd", "2xx")
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), `http_requests_total{method="GET",route="/a\"b\\c\nd",status_class="2xx"} 1`+"\n")
+}
+
+func TestUnknownMethods(t *testing.T) {
+	m := New()
+	h := m.Middleware()(func(http.ResponseWriter, *http.Request) {})
+
+	for _, method := range []string{"PROPFIND", "X1", "X2"} {
+		h(httptest.NewRecorder(), httptest.NewRequest(method, "/", nil))
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), `http_requests_total{method="OTHER",route="unmatched",status_class="2xx"} 3`+"\n")
+	assert.NotContains(t, rec.Body.String(), "PROPFIND")
+}
@@ -0,0 +1,111 @@
+package webapp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mountMethods are the methods forwarded to a mounted handler.
+var mountMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace,
+}
+
+type mountConfig struct {
+	preservePath bool
+}
+
+// MountOption configures how a handler is mounted.
+type MountOption func(*mountConfig)
+
+// PreservePath forwards the request to the mounted handler with the prefix still in the path, by default it is stripped.
+func PreservePath() MountOption {
+	return func(c *mountConfig) {
+		c.preservePath = true
+	}
+}
+
+// mounted is a mounted API, its routes are listed by Routes with the prefix applied.
+type mounted struct {
+	api   *API
+	strip bool
+}
+
+// Mount forwards every method and sub path under the prefix to the handler, e.g. another API, an http.ServeMux or an
+// existing admin UI. The prefix is stripped from the path unless PreservePath is given. The routes of a mounted API
+// are listed by Routes with the prefix applied, other handlers are listed as a single route with the method *.
+// The root path cannot be mounted as it conflicts with the routes of the API, set API.NotFound to the handler instead.
+//
+//	api.Mount("/billing", billing.NewAPI())
+func (r *API) Mount(prefix string, handler http.Handler, options ...MountOption) {
+	cfg := &mountConfig{}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		panic("webapp: cannot mount on the root path, set API.NotFound to the handler instead")
+	}
+
+	route := &Route{Method: "*", Path: prefix + "/*"}
+	if api, ok := handler.(*API); ok {
+		route.mounted = &mounted{api: api, strip: !cfg.preservePath}
+	}
+
+	h := handler
+	if !cfg.preservePath {
+		h = stripPrefix(prefix, handler)
+	}
+	h = r.serveRoute(route, h, nil)
+
+	for _, method := range mountMethods {
+		r.router.Handler(method, prefix, h)
+		r.router.Handler(method, prefix+"/*mountpath", h)
+	}
+}
+
+// ServeHTTP serves the request with the handler of RequestHander, the handler is built on the first request.
+// The API cannot be changed after, registering routes or middleware panics.
+func (r *API) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	r.serveOnce.Do(func() {
+		r.serve = r.RequestHander()
+		r.serving.Store(true)
+	})
+	r.serve(rw, req)
+}
+
+// checkModifiable panics when the API is changed after ServeHTTP built its handler, the change would be ignored.
+func (r *API) checkModifiable() {
+	if r.serving.Load() {
+		panic("webapp: the API cannot be changed after it started serving")
+	}
+}
+
+type mountPrefixKey struct{}
+
+// MountPrefix returns the prefixes stripped from the path of the request by the mounts it passed, so links to the
+// routes of a mounted API can be built.
+func MountPrefix(ctx context.Context) string {
+	prefix, _ := ctx.Value(mountPrefixKey{}).(string)
+	return prefix
+}
+
+// stripPrefix removes the prefix from the path, the stripped prefix is kept in the context so the route of a mounted API
+// is reported with the prefix.
+func stripPrefix(prefix string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		u := new(url.URL)
+		*u = *req.URL
+		u.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, prefix), "/")
+		if req.URL.RawPath != "" {
+			u.RawPath = "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.RawPath, prefix), "/")
+		}
+
+		r := req.WithContext(context.WithValue(req.Context(), mountPrefixKey{}, MountPrefix(req.Context())+prefix))
+		r.URL = u
+		h.ServeHTTP(rw, r)
+	})
+}
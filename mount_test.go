@@ -0,0 +1,112 @@
+package webapp
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMountAPI(t *testing.T) {
+	type invoiceRequest struct {
+		ID string `path:"id"`
+	}
+
+	billing := New(nil)
+	billing.Get("/invoices/@id", H(func(ctx context.Context, req invoiceRequest) (string, error) {
+		return req.ID + " " + RequestInfoFromContext(ctx).Route, nil
+	}))
+
+	api := New(nil)
+	api.Get("/health", func(rw http.ResponseWriter, req *http.Request) {})
+	api.Mount("/billing/", billing)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/billing/invoices/42")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "\"42 /billing/invoices/@id\"\n", rec.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/billing/unknown").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPost, "/billing/invoices/42").Code)
+
+	assert.Equal(t, []Route{
+		{Method: http.MethodGet, Path: "/health"},
+		{Method: http.MethodGet, Path: "/billing/invoices/@id", Params: []PathParam{{Name: "id"}}},
+	}, api.Routes())
+}
+
+func TestMountHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(req.Method + " " + req.URL.Path))
+	})
+
+	api := New(nil)
+	api.Mount("/admin", mux)
+	api.Mount("/legacy", mux, PreservePath())
+
+	serve := func(method, target string) string {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, httptest.NewRequest(method, target, nil))
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "GET /", serve(http.MethodGet, "/admin"))
+	assert.Equal(t, "DELETE /users/1", serve(http.MethodDelete, "/admin/users/1"))
+	assert.Equal(t, "PUT /legacy/users/1", serve(http.MethodPut, "/legacy/users/1"))
+
+	assert.Equal(t, []Route{
+		{Method: "*", Path: "/admin/*"},
+		{Method: "*", Path: "/legacy/*"},
+	}, api.Routes())
+}
+
+func TestMountRoot(t *testing.T) {
+	api := New(nil)
+	api.Get("/health", func(rw http.ResponseWriter, req *http.Request) {})
+
+	for _, prefix := range []string{"", "/"} {
+		assert.PanicsWithValue(t, "webapp: cannot mount on the root path, set API.NotFound to the handler instead", func() {
+			api.Mount(prefix, http.NotFoundHandler())
+		})
+	}
+}
+
+func TestModifyAfterServing(t *testing.T) {
+	billing := New(nil)
+	billing.Get("/invoices", func(rw http.ResponseWriter, req *http.Request) {})
+
+	api := New(nil)
+	api.Mount("/billing", billing)
+	api.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/billing/invoices", nil))
+
+	const msg = "webapp: the API cannot be changed after it started serving"
+	assert.PanicsWithValue(t, msg, func() { api.Get("/health", func(rw http.ResponseWriter, req *http.Request) {}) })
+	assert.PanicsWithValue(t, msg, func() { api.Group("/admin").Get("/health", func(rw http.ResponseWriter, req *http.Request) {}) })
+	assert.PanicsWithValue(t, msg, func() { api.Use(RequestID()) })
+	assert.PanicsWithValue(t, msg, func() { api.Mount("/other", http.NotFoundHandler()) })
+	assert.PanicsWithValue(t, msg, func() { billing.Get("/late", func(rw http.ResponseWriter, req *http.Request) {}) })
+}
+
+func TestMountRequire(t *testing.T) {
+	api := New(nil)
+	api.Authorizer = AuthorizerFunc(func(ctx context.Context, permissions []string, request any) error {
+		return ErrForbidden
+	})
+	api.Use(Require("admin"))
+	api.Mount("/admin", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("admin"))
+	}))
+
+	rec := httptest.NewRecorder()
+	api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/users", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "admin")
+}
@@ -1,6 +1,9 @@
 package webapp
 
-import "strings"
+import (
+	"mime"
+	"strings"
+)
 
 type Negotiator[T any] interface {
 	Get(mimetype string) (T, error)
@@ -17,29 +20,58 @@ type negotiator[T any] struct {
 	aliases   map[string]string
 }
 
-func stripEncoding(s string) string {
-	index := strings.IndexRune(s, ';')
-	if index > 0 {
-		s = s[:index]
+// parseMediaType returns the media type and its parameters, application/vnd.acme+json; version=2.
+func parseMediaType(s string) (string, map[string]string) {
+	mimetype, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		mimetype, _, _ = strings.Cut(s, ";")
+		return strings.TrimSpace(mimetype), nil
 	}
-	return strings.TrimSpace(s)
+	return mimetype, params
+}
+
+// MediaTypeParam returns the parameter of the first media type in the header that has it, e.g. the version of
+// Accept: application/vnd.acme+json; version=2.
+func MediaTypeParam(header string, name string) string {
+	for _, mediatype := range strings.Split(header, ",") {
+		if _, params := parseMediaType(mediatype); params[name] != "" {
+			return params[name]
+		}
+	}
+	return ""
 }
 
 func (n negotiator[T]) Get(contentTypes string) (h T, e error) {
 	for _, mimetype := range strings.Split(contentTypes, ",") {
-		mimetype = stripEncoding(mimetype)
+		mimetype, _ = parseMediaType(mimetype)
 
-		if v, ok := n.encodings[mimetype]; ok {
+		if v, ok := n.lookup(mimetype); ok {
 			return v, nil
 		}
 
-		if name := n.aliases[mimetype]; name != "" {
-			return n.encodings[name], nil
+		//a vendor type with a structured syntax suffix, application/vnd.acme+json is encoded as application/json
+		if i := strings.LastIndexByte(mimetype, '+'); i > 0 && !strings.Contains(mimetype[i:], "/") {
+			if v, ok := n.lookup("application/" + mimetype[i+1:]); ok {
+				return v, nil
+			}
 		}
 	}
 	return h, ErrNotAcceptable
 }
 
+func (n negotiator[T]) lookup(mimetype string) (T, bool) {
+	if v, ok := n.encodings[mimetype]; ok {
+		return v, true
+	}
+
+	if name := n.aliases[mimetype]; name != "" {
+		return n.encodings[name], true
+	}
+
+	var zero T
+	return zero, false
+}
+
 func (n negotiator[T]) Register(mimetype string, v T, aliases ...string) {
 	n.encodings[mimetype] = v
 	for _, alias := range aliases {
@@ -1,9 +1,15 @@
 package webapp
 
 import (
+	"context"
+	"fmt"
 	"github.com/mbict/go-webapp/container"
+	"github.com/mbict/go-webapp/decoder"
 	"github.com/mbict/go-webapp/encoding/json"
 	"github.com/mbict/go-webapp/encoding/xml"
+	"log/slog"
+	"net/http"
+	"sync"
 )
 
 var DefaultOptions = Options{
@@ -11,6 +17,7 @@ var DefaultOptions = Options{
 	AcceptsJson("*/*"),
 	OutputsJson("*/*"),
 	WithDefaultJSONOutputEncoding(),
+	MapError(context.DeadlineExceeded, ErrorMapping{Status: http.StatusGatewayTimeout}),
 	//WithErrorHandler(func(err error) error {
 	//	return err
 	//}),
@@ -22,8 +29,116 @@ func (o *Options) Add(option ...Option) Options {
 	return append(*o, option...)
 }
 
+// Option configures a handler. Options stack, the DefaultOptions are applied first, followed by the options of New,
+// the ones of UseOptions and finally the options passed to H, so later options extend or override earlier ones.
 type Option func(ctx *HandlerContext)
 
+// optionLayer is a set of options stacked on the options of its parent.
+type optionLayer struct {
+	parent  *optionLayer
+	options []Option
+
+	//children caches the layer stacked on a parent, so the layers of a route are the same for every request
+	children sync.Map
+}
+
+func (l *optionLayer) stack() []Option {
+	if l == nil {
+		return nil
+	}
+	return append(l.parent.stack(), l.options...)
+}
+
+type optionLayerKey struct{}
+
+func optionLayerFromContext(ctx context.Context) *optionLayer {
+	layer, _ := ctx.Value(optionLayerKey{}).(*optionLayer)
+	return layer
+}
+
+// handlerContexts resolves the handler context of a request, the options are stacked on the option layer of the
+// request. Requests without a layer, e.g. when the handler is served without an API, use the context of the options.
+type handlerContexts struct {
+	options  []Option
+	fallback *HandlerContext
+
+	//resolved caches the handler context per option layer
+	resolved sync.Map
+}
+
+func newHandlerContexts(options []Option) *handlerContexts {
+	checkHandlerOptions(options)
+	return &handlerContexts{options: options, fallback: newHandlerContext(options)}
+}
+
+func (c *handlerContexts) of(req *http.Request) *HandlerContext {
+	return c.ofContext(req.Context())
+}
+
+func (c *handlerContexts) ofContext(ctx context.Context) *HandlerContext {
+	layer := optionLayerFromContext(ctx)
+	if layer == nil {
+		return c.fallback
+	}
+	if handlerCtx, ok := c.resolved.Load(layer); ok {
+		return handlerCtx.(*HandlerContext)
+	}
+	handlerCtx, _ := c.resolved.LoadOrStore(layer, newHandlerContext(append(layer.stack(), c.options...)))
+	return handlerCtx.(*HandlerContext)
+}
+
+// UseOptions returns a middleware that applies the options to the handlers created with H of the routes it is used on,
+// on top of the options of the API and enclosing groups. The options that configure the API, WithTrustedProxies,
+// WithTracerProvider, WithVersioning and WithDefaultVersion, are only accepted by New and panic here.
+//
+//	admin := api.Group("/admin", webapp.UseOptions(webapp.AcceptsXML(), webapp.OutputsXML()))
+func UseOptions(options ...Option) Middleware {
+	checkHandlerOptions(options)
+	root := &optionLayer{options: options}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			layer := root
+			if parent := optionLayerFromContext(req.Context()); parent != nil {
+				l, _ := root.children.LoadOrStore(parent, &optionLayer{parent: parent, options: options})
+				layer = l.(*optionLayer)
+			}
+			next(rw, req.WithContext(context.WithValue(req.Context(), optionLayerKey{}, layer)))
+		}
+	}
+}
+
+// checkHandlerOptions panics on the options that configure the API, these are only read by New and would silently do
+// nothing on a handler or group.
+func checkHandlerOptions(options []Option) {
+	if len(options) == 0 {
+		return
+	}
+
+	probe := &HandlerContext{
+		encoderNegotiator: NewNegotiatorBuilder[Encoder](),
+		decoderNegotiator: NewNegotiatorBuilder[Decoder](),
+	}
+	for _, option := range options {
+		option(probe)
+	}
+
+	name := ""
+	switch {
+	case probe.trustedProxies != nil:
+		name = "WithTrustedProxies"
+	case probe.tracerProvider != nil:
+		name = "WithTracerProvider"
+	case probe.versionSources != nil:
+		name = "WithVersioning"
+	case probe.defaultVersion != "":
+		name = "WithDefaultVersion"
+	default:
+		return
+	}
+	panic(fmt.Sprintf("webapp: %s configures the API, pass it to New", name))
+}
+
 func AcceptsJson(mediatypeAlias ...string) Option {
 	return func(ctx *HandlerContext) {
 		ctx.decoderNegotiator.Register("application/json", json.NewJsonEncoding(), mediatypeAlias...)
@@ -66,8 +181,74 @@ func WithContainer(container container.Container) Option {
 	}
 }
 
+// WithErrorHandler sets the handler converting errors without an error mapping, see MapError, to a StatusCoder.
 func WithErrorHandler(handler func(error) error) Option {
 	return func(ctx *HandlerContext) {
 		ctx.errorHandler = handler
 	}
 }
+
+// WithAuthorizer sets the authorizer that evaluates the permissions declared with Require for this handler.
+func WithAuthorizer(authorizer Authorizer) Option {
+	return func(ctx *HandlerContext) {
+		ctx.authorizer = authorizer
+	}
+}
+
+// WithLogger sets the logger used for the internal logging of the handler, defaults to the slog default logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(ctx *HandlerContext) {
+		ctx.logger = logger
+	}
+}
+
+// WithSourceOrder sets the precedence of the binding sources, from low to high. The values of a later source overwrite the
+// ones of an earlier source, sources that are not listed are decoded first. Pass it to New to apply it to the whole API.
+//
+//	webapp.New(c, webapp.WithSourceOrder("query", "header", "path"))
+func WithSourceOrder(tags ...string) Option {
+	return func(ctx *HandlerContext) {
+		ctx.sourceOrder = tags
+	}
+}
+
+// StrictQuery rejects requests with query parameters that are not bound to the request, the error lists the accepted ones.
+func StrictQuery() Option {
+	return func(ctx *HandlerContext) {
+		ctx.strictQuery = true
+	}
+}
+
+// WithTrustedProxies sets the proxies that are trusted to forward the client address with the Forwarded or X-Forwarded-For
+// header, used by the request:"client-ip" binding. Proxies are addresses or networks in CIDR notation. It configures the
+// API, so it is only accepted by New.
+//
+//	webapp.New(c, webapp.WithTrustedProxies("10.0.0.0/8", "192.168.1.1"))
+func WithTrustedProxies(proxies ...string) Option {
+	trusted, err := decoder.ParseTrustedProxies(proxies...)
+	if err != nil {
+		panic(fmt.Errorf("webapp: invalid trusted proxy: %w", err))
+	}
+
+	return func(ctx *HandlerContext) {
+		ctx.trustedProxies = trusted
+	}
+}
+
+// WithVersioning sets how the version of a request is selected, in order of precedence. It configures the API, so it is
+// only accepted by New.
+//
+//	webapp.New(c, webapp.WithVersioning(webapp.VersionHeader("Api-Version"), webapp.VersionMediaType()))
+func WithVersioning(sources ...VersionSource) Option {
+	return func(ctx *HandlerContext) {
+		ctx.versionSources = sources
+	}
+}
+
+// WithDefaultVersion sets the version that serves requests that do not ask for a version, by default the latest
+// version of the route. It configures the API, so it is only accepted by New.
+func WithDefaultVersion(version string) Option {
+	return func(ctx *HandlerContext) {
+		ctx.defaultVersion = version
+	}
+}
@@ -0,0 +1,124 @@
+package webapp
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type greeting struct {
+	Name string `json:"name" xml:"name" query:"name"`
+}
+
+func greet(ctx context.Context, req greeting) (greeting, error) {
+	return req, nil
+}
+
+func TestOptionsExtendDefaults(t *testing.T) {
+	api := New(nil)
+	api.Post("/greet", H(greet, AcceptsXML()))
+
+	serve := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/greet", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	rec := serve("application/xml", "<greeting><name>xml</name></greeting>")
+	assert.Equal(t, "{\"name\":\"xml\"}\n", rec.Body.String())
+
+	//json is still accepted
+	rec = serve("application/json", `{"name":"json"}`)
+	assert.Equal(t, "{\"name\":\"json\"}\n", rec.Body.String())
+}
+
+func TestOptionsStack(t *testing.T) {
+	api := New(nil, WithErrorHandler(func(err error) error {
+		return Error(err, http.StatusTeapot)
+	}))
+	api.Get("/fail", H(func(ctx context.Context, req Empty) (*Empty, error) {
+		return nil, errors.New("failed")
+	}))
+
+	xmlGroup := api.Group("/xml", UseOptions(OutputsXML()))
+	xmlGroup.Get("/greet", H(greet))
+	xmlGroup.Get("/strict", H(greet), UseOptions(StrictQuery()))
+
+	serve := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	//api level error handler
+	rec := serve("/fail", "application/json")
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+
+	//group level encoder, on top of the json default
+	rec = serve("/xml/greet?name=xml", "application/xml")
+	assert.Equal(t, "<greeting><name>xml</name></greeting>", rec.Body.String())
+
+	rec = serve("/xml/greet?name=json", "application/json")
+	assert.Equal(t, "{\"name\":\"json\"}\n", rec.Body.String())
+
+	//route level options
+	rec = serve("/xml/strict?name=xml&unknown=1", "application/xml")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve("/xml/greet?name=xml&unknown=1", "application/xml")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestDefaultEncoding(t *testing.T) {
+	api := New(nil, OutputsXML(), WithDefaultOutputEncoding("application/xml"))
+	api.Get("/greet", H(greet))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/greet?name=xml", nil))
+
+	assert.Equal(t, "<greeting><name>xml</name></greeting>", rec.Body.String())
+}
+
+func TestErrorHandlerStacksOptions(t *testing.T) {
+	errDenied := errors.New("denied")
+	errorHandler := ErrorHandler()
+	deny := func(next http.HandlerFunc) http.HandlerFunc {
+		return errorHandler(errDenied)
+	}
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	api := New(nil, MapError(errDenied, ErrorMapping{Status: http.StatusTeapot}))
+	api.Get("/res", noop, deny)
+	api.Group("/group", UseOptions(MapError(errDenied, ErrorMapping{Status: http.StatusConflict}))).Get("/res", noop, deny)
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/res", nil))
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/group/res", nil))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestAPIOptionsRejectedOnHandlers(t *testing.T) {
+	assert.PanicsWithValue(t, "webapp: WithTrustedProxies configures the API, pass it to New", func() {
+		H(greet, WithTrustedProxies("10.0.0.0/8"))
+	})
+	assert.PanicsWithValue(t, "webapp: WithVersioning configures the API, pass it to New", func() {
+		UseOptions(AcceptsXML(), WithVersioning(VersionHeader("X-Version")))
+	})
+	assert.PanicsWithValue(t, "webapp: WithDefaultVersion configures the API, pass it to New", func() {
+		UseOptions(WithDefaultVersion("1"))
+	})
+	assert.NotPanics(t, func() {
+		New(nil, WithTrustedProxies("10.0.0.0/8"), WithDefaultVersion("1"))
+	})
+}
@@ -0,0 +1,130 @@
+package webapp
+
+import (
+	"github.com/google/uuid"
+	"github.com/mbict/httprouter"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// PathParam is a parameter of a route pattern, /res/@id:uuid has the parameter id of type uuid.
+type PathParam struct {
+	Name string
+
+	// Type is the constraint the value must match, empty when any value matches.
+	Type string
+
+	// CatchAll is a *name parameter that matches the rest of the path, /files/*path.
+	CatchAll bool
+}
+
+var (
+	pathTypesMu sync.RWMutex
+	pathTypes   = map[string]func(string) bool{
+		"int": func(s string) bool {
+			_, err := strconv.ParseInt(s, 10, 64)
+			return err == nil
+		},
+		"uint": func(s string) bool {
+			_, err := strconv.ParseUint(s, 10, 64)
+			return err == nil
+		},
+		"uuid": func(s string) bool {
+			_, err := uuid.Parse(s)
+			return err == nil && len(s) == 36
+		},
+		"alpha": func(s string) bool {
+			return s != "" && strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) }) == -1
+		},
+		"alnum": func(s string) bool {
+			return s != "" && strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) == -1
+		},
+	}
+)
+
+// RegisterPathType registers a type that path parameters can be constrained to, /res/@code:country. Routes are parsed
+// when they are registered, so register the types before the routes.
+func RegisterPathType(name string, match func(string) bool) {
+	pathTypesMu.Lock()
+	defer pathTypesMu.Unlock()
+
+	pathTypes[name] = match
+}
+
+func pathType(name string) func(string) bool {
+	pathTypesMu.RLock()
+	defer pathTypesMu.RUnlock()
+
+	return pathTypes[name]
+}
+
+// parsePattern returns the pattern for the router without the type constraints, and the parameters of the pattern.
+// A :suffix that is not a registered type is kept as literal, /res/@id:publish.
+func parsePattern(pattern string) (string, []PathParam) {
+	var b strings.Builder
+	var params []PathParam
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		b.WriteByte(c)
+
+		switch c {
+		case '*':
+			params = append(params, PathParam{Name: pattern[i+1:], CatchAll: true})
+			b.WriteString(pattern[i+1:])
+			return b.String(), params
+		case '@':
+			end := i + 1
+			for end < len(pattern) && pattern[end] != '/' && pattern[end] != ':' {
+				end++
+			}
+			param := PathParam{Name: pattern[i+1 : end]}
+			b.WriteString(param.Name)
+			i = end - 1
+
+			if end < len(pattern) && pattern[end] == ':' {
+				typeEnd := end + 1
+				for typeEnd < len(pattern) && pattern[typeEnd] != '/' && pattern[typeEnd] != ':' {
+					typeEnd++
+				}
+
+				if typ := pattern[end+1 : typeEnd]; pathType(typ) != nil {
+					param.Type = typ
+					i = typeEnd - 1
+				}
+			}
+			params = append(params, param)
+		}
+	}
+	return b.String(), params
+}
+
+// matchPathTypes returns the check of the typed parameters, nil when no parameter is typed.
+func matchPathTypes(params []PathParam) func(httprouter.Params) bool {
+	type typed struct {
+		name  string
+		match func(string) bool
+	}
+
+	var checks []typed
+	for _, p := range params {
+		if p.Type != "" {
+			checks = append(checks, typed{name: p.Name, match: pathType(p.Type)})
+		}
+	}
+
+	if len(checks) == 0 {
+		return nil
+	}
+
+	return func(ps httprouter.Params) bool {
+		for _, check := range checks {
+			if !check.match(ps.ByName(check.name)) {
+				return false
+			}
+		}
+		return true
+	}
+}
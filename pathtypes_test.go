@@ -0,0 +1,85 @@
+package webapp
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+		params   []PathParam
+	}{
+		{pattern: "/res", expected: "/res"},
+		{pattern: "/res/@id", expected: "/res/@id", params: []PathParam{{Name: "id"}}},
+		{pattern: "/res/@id:uuid/sub/@page:int", expected: "/res/@id/sub/@page", params: []PathParam{{Name: "id", Type: "uuid"}, {Name: "page", Type: "int"}}},
+		{pattern: "/res/@id:publish", expected: "/res/@id:publish", params: []PathParam{{Name: "id"}}},
+		{pattern: "/res/@id:int:publish", expected: "/res/@id:publish", params: []PathParam{{Name: "id", Type: "int"}}},
+		{pattern: "/files/@dir:alpha/*path", expected: "/files/@dir/*path", params: []PathParam{{Name: "dir", Type: "alpha"}, {Name: "path", CatchAll: true}}},
+	}
+
+	for _, test := range tests {
+		pattern, params := parsePattern(test.pattern)
+		assert.Equal(t, test.expected, pattern, test.pattern)
+		assert.Equal(t, test.params, params, test.pattern)
+	}
+}
+
+func TestTypedPathParams(t *testing.T) {
+	type request struct {
+		ID   string `path:"id"`
+		Page int    `path:"page"`
+	}
+
+	api := New(nil)
+	api.Get("/res/@id:uuid/@page:int", H(func(ctx context.Context, req request) (string, error) {
+		return req.ID, nil
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := serve("/res/6ba7b810-9dad-11d1-80b4-00c04fd430c8/2")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "\"6ba7b810-9dad-11d1-80b4-00c04fd430c8\"\n", rec.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, serve("/res/abc/2").Code)
+	assert.Equal(t, http.StatusNotFound, serve("/res/6ba7b810-9dad-11d1-80b4-00c04fd430c8/two").Code)
+
+	assert.Equal(t, []PathParam{{Name: "id", Type: "uuid"}, {Name: "page", Type: "int"}}, api.Routes()[0].Params)
+}
+
+func TestCatchAllPathParam(t *testing.T) {
+	type request struct {
+		Path     string   `path:"path"`
+		Segments []string `path:"path"`
+	}
+
+	api := New(nil)
+	api.Get("/files/*path", H(func(ctx context.Context, req request) (string, error) {
+		return req.Path + " " + strings.Join(req.Segments, ","), nil
+	}))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/files/docs/2024/report.pdf", nil))
+	assert.Equal(t, "\"/docs/2024/report.pdf docs,2024,report.pdf\"\n", rec.Body.String())
+}
+
+func TestRegisterPathType(t *testing.T) {
+	RegisterPathType("lower", func(s string) bool { return strings.ToLower(s) == s })
+
+	api := New(nil)
+	api.Get("/tags/@tag:lower", func(rw http.ResponseWriter, req *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/tags/Go", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Result is the outcome of a limiter decision.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+
+	// Reset is the time until the limit is fully restored.
+	Reset time.Duration
+
+	// RetryAfter is the time until the next request would be allowed, only set when the request is denied.
+	RetryAfter time.Duration
+}
+
+// Limiter decides if a request for the key is allowed.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+type tokenBucket struct {
+	store Store
+	burst int
+	rate  float64 // tokens per second
+	now   func() time.Time
+}
+
+// NewTokenBucket allows bursts of up to burst requests, refilled with limit requests per period.
+// Limit must be at least 1 and the period positive.
+func NewTokenBucket(store Store, limit int, period time.Duration, burst int) Limiter {
+	if limit < 1 || period <= 0 {
+		panic(fmt.Sprintf("ratelimit: invalid token bucket of %d requests per %s", limit, period))
+	}
+
+	if burst < 1 {
+		burst = limit
+	}
+
+	return &tokenBucket{
+		store: store,
+		burst: burst,
+		rate:  float64(limit) / period.Seconds(),
+		now:   time.Now,
+	}
+}
+
+func (l *tokenBucket) Allow(ctx context.Context, key string) (Result, error) {
+	res := Result{Limit: l.burst}
+	now := l.now()
+
+	//time it takes to refill the bucket completely, after which the state is useless
+	ttl := seconds(float64(l.burst) / l.rate)
+
+	err := l.store.Update(ctx, "tb:"+key, ttl, func(s *State) {
+		if s.Start.IsZero() {
+			s.Tokens = float64(l.burst)
+		} else {
+			s.Tokens = math.Min(float64(l.burst), s.Tokens+now.Sub(s.Start).Seconds()*l.rate)
+		}
+		s.Start = now
+
+		if s.Tokens >= 1 {
+			s.Tokens--
+			res.Allowed = true
+		} else {
+			res.RetryAfter = seconds((1 - s.Tokens) / l.rate)
+		}
+
+		res.Remaining = int(s.Tokens)
+		res.Reset = seconds((float64(l.burst) - s.Tokens) / l.rate)
+	})
+
+	return res, err
+}
+
+type slidingWindow struct {
+	store  Store
+	limit  int
+	window time.Duration
+	now    func() time.Time
+}
+
+// NewSlidingWindow allows limit requests in any window of the given length. The window is approximated
+// by weighting the count of the previous fixed window.
+func NewSlidingWindow(store Store, limit int, window time.Duration) Limiter {
+	return &slidingWindow{
+		store:  store,
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+	}
+}
+
+func (l *slidingWindow) Allow(ctx context.Context, key string) (Result, error) {
+	res := Result{Limit: l.limit}
+	now := l.now()
+	start := now.Truncate(l.window)
+
+	err := l.store.Update(ctx, "sw:"+key, 2*l.window, func(s *State) {
+		switch {
+		case s.Start.Equal(start):
+		case s.Start.Equal(start.Add(-l.window)):
+			s.Prev, s.Count, s.Start = s.Count, 0, start
+		default:
+			s.Prev, s.Count, s.Start = 0, 0, start
+		}
+
+		elapsed := now.Sub(start)
+		weight := 1 - float64(elapsed)/float64(l.window)
+		estimated := float64(s.Prev)*weight + float64(s.Count)
+
+		if estimated+1 <= float64(l.limit) {
+			s.Count++
+			estimated++
+			res.Allowed = true
+		} else {
+			res.RetryAfter = l.retryAfter(s, elapsed)
+		}
+
+		res.Remaining = int(math.Max(0, float64(l.limit)-math.Ceil(estimated)))
+		res.Reset = l.window - elapsed
+		if s.Count > 0 {
+			res.Reset += l.window
+		}
+	})
+
+	return res, err
+}
+
+// retryAfter calculates when the weighted previous window has decayed enough to allow one more request.
+func (l *slidingWindow) retryAfter(s *State, elapsed time.Duration) time.Duration {
+	free := float64(l.limit) - float64(s.Count) - 1
+	if free < 0 || s.Prev == 0 {
+		return l.window - elapsed
+	}
+
+	weight := free / float64(s.Prev)
+	wait := time.Duration((1-weight)*float64(l.window)) - elapsed
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
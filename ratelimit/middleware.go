@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"fmt"
+	"github.com/mbict/go-webapp"
+	"github.com/mbict/go-webapp/auth"
+	"github.com/mbict/go-webapp/decoder"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// KeyFunc returns the key a request is limited by. Requests with an empty key are not limited.
+type KeyFunc func(req *http.Request) string
+
+// ByIP limits requests by the address of the client. Behind the proxies trusted with webapp.WithTrustedProxies the
+// client address is taken from the forwarded headers, see decoder.ClientIP.
+func ByIP(req *http.Request) string {
+	if ip := decoder.ClientIP(req); ip != "" {
+		return "ip:" + ip
+	}
+
+	//malformed forwarded headers are limited by the address of the proxy
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ByPrincipal limits requests by the subject of the authenticated principal,
+// anonymous requests are limited by IP.
+func ByPrincipal(req *http.Request) string {
+	if p, ok := auth.FromContext(req.Context()); ok && p.Subject != "" {
+		return "principal:" + p.Subject
+	}
+	return ByIP(req)
+}
+
+// ByAPIKey limits requests by the API key in the named header, requests without a key are limited by IP.
+func ByAPIKey(header string) KeyFunc {
+	return func(req *http.Request) string {
+		if key := req.Header.Get(header); key != "" {
+			return "apikey:" + key
+		}
+		return ByIP(req)
+	}
+}
+
+// Prefix scopes the keys, so limiters sharing a store do not share their limits.
+func Prefix(prefix string, key KeyFunc) KeyFunc {
+	return func(req *http.Request) string {
+		if k := key(req); k != "" {
+			return prefix + ":" + k
+		}
+		return ""
+	}
+}
+
+// Limit returns middleware that rejects requests over the limit with 429 Too Many Requests.
+// The RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers are added to every limited response.
+func Limit(limiter Limiter, key KeyFunc) webapp.Middleware {
+	errorHandler := webapp.ErrorHandler()
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			k := key(req)
+			if k == "" {
+				next(rw, req)
+				return
+			}
+
+			res, err := limiter.Allow(req.Context(), k)
+			if err != nil {
+				errorHandler(webapp.Error(err, http.StatusInternalServerError))(rw, req)
+				return
+			}
+
+			h := rw.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+
+			if !res.Allowed {
+				errorHandler(&limitError{webapp.ErrTooManyRequests, res.RetryAfter})(rw, req)
+				return
+			}
+
+			next(rw, req)
+		}
+	}
+}
+
+// Concurrency returns middleware that limits the number of requests a route handles at the same time.
+// Excess requests are shed with 503 Service Unavailable. Every route the middleware is applied to gets its own limit.
+// Max must be at least 1.
+func Concurrency(max int) webapp.Middleware {
+	if max < 1 {
+		panic(fmt.Sprintf("ratelimit: invalid concurrency %d, must be at least 1", max))
+	}
+
+	errorHandler := webapp.ErrorHandler()
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		slots := make(chan struct{}, max)
+
+		return func(rw http.ResponseWriter, req *http.Request) {
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				next(rw, req)
+			default:
+				errorHandler(&limitError{webapp.ErrServiceUnavailable, time.Second})(rw, req)
+			}
+		}
+	}
+}
+
+type limitError struct {
+	webapp.StatusError
+	retryAfter time.Duration
+}
+
+func (e *limitError) Header() http.Header {
+	h := http.Header{}
+	h.Set("Retry-After", ceilSeconds(e.retryAfter))
+	return h
+}
+
+func (e *limitError) Unwrap() error {
+	return e.StatusError
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
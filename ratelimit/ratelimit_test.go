@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/mbict/go-webapp/decoder"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func TestTokenBucket(t *testing.T) {
+	c := &clock{time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewTokenBucket(NewMemoryStore(), 1, time.Second, 3).(*tokenBucket)
+	l.now = c.now
+
+	for i := 2; i >= 0; i-- {
+		res, err := l.Allow(context.Background(), "key")
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res, _ := l.Allow(context.Background(), "key")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 3*time.Second, res.Reset)
+
+	res, _ = l.Allow(context.Background(), "other")
+	assert.True(t, res.Allowed)
+
+	c.t = c.t.Add(time.Second)
+	res, _ = l.Allow(context.Background(), "key")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	c := &clock{time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := NewSlidingWindow(NewMemoryStore(), 4, time.Minute).(*slidingWindow)
+	l.now = c.now
+
+	for i := 0; i < 4; i++ {
+		res, _ := l.Allow(context.Background(), "key")
+		assert.True(t, res.Allowed)
+	}
+
+	res, _ := l.Allow(context.Background(), "key")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	//halfway the next window half of the previous window still counts
+	c.t = c.t.Add(90 * time.Second)
+	for i := 0; i < 2; i++ {
+		res, _ = l.Allow(context.Background(), "key")
+		assert.True(t, res.Allowed)
+	}
+	res, _ = l.Allow(context.Background(), "key")
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 15*time.Second, res.RetryAfter)
+
+	c.t = c.t.Add(3 * time.Minute)
+	res, _ = l.Allow(context.Background(), "key")
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 3, res.Remaining)
+}
+
+func TestLimitMiddleware(t *testing.T) {
+	h := Limit(NewTokenBucket(NewMemoryStore(), 1, time.Minute, 1), ByAPIKey("X-Api-Key"))(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", "key")
+
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+
+	rec = httptest.NewRecorder()
+	h(rec, req)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"message":"Too Many Requests"}`, rec.Body.String())
+
+	//a different key has its own limit
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	started := sync.WaitGroup{}
+	started.Add(1)
+
+	h := Concurrency(1)(func(rw http.ResponseWriter, req *http.Request) {
+		started.Done()
+		<-release
+	})
+
+	done := make(chan struct{})
+	go func() {
+		h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	started.Wait()
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	close(release)
+	<-done
+
+	started.Add(1)
+	go func() { <-release }()
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestByIP(t *testing.T) {
+	proxies, err := decoder.ParseTrustedProxies("10.0.0.0/8")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.1.2.3:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 10.0.0.2")
+	assert.Equal(t, "ip:10.1.2.3", ByIP(req))
+
+	//behind a trusted proxy the client is limited by its own address
+	req = req.WithContext(context.WithValue(req.Context(), decoder.TrustedProxiesKey, proxies))
+	assert.Equal(t, "ip:198.51.100.1", ByIP(req))
+
+	//a malformed forwarded address is limited by the proxy
+	req.Header.Set("X-Forwarded-For", "not-an-ip")
+	assert.Equal(t, "ip:10.1.2.3", ByIP(req))
+}
+
+func TestConcurrencyInvalidMax(t *testing.T) {
+	assert.Panics(t, func() { Concurrency(0) })
+	assert.Panics(t, func() { Concurrency(-1) })
+}
+
+func TestTokenBucketInvalidRate(t *testing.T) {
+	assert.Panics(t, func() { NewTokenBucket(NewMemoryStore(), 0, time.Second, 1) })
+	assert.Panics(t, func() { NewTokenBucket(NewMemoryStore(), 10, 0, 1) })
+	assert.Panics(t, func() { NewTokenBucket(NewMemoryStore(), 10, -time.Second, 1) })
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State is the per key bookkeeping of a limiter.
+type State struct {
+	// Tokens left in the bucket, used by the token bucket.
+	Tokens float64
+
+	// Count of requests in the current window and Prev in the previous one, used by the sliding window.
+	Count int64
+	Prev  int64
+
+	// Start of the current window, or the time of the last refill of the bucket.
+	Start time.Time
+}
+
+// Store keeps the limiter state. Implementations must apply fn atomically per key, so a distributed
+// store can be used to share limits between processes.
+type Store interface {
+	// Update loads the state for key, applies fn and stores the result. The state may be dropped
+	// after ttl without updates. A missing key is passed to fn as a zero State.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state *State)) error
+}
+
+type memoryEntry struct {
+	state   State
+	expires time.Time
+}
+
+// MemoryStore is an in-memory Store for a single process.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Update(_ context.Context, key string, ttl time.Duration, fn func(state *State)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	e, ok := s.entries[key]
+	if !ok || now.After(e.expires) {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+
+	fn(&e.state)
+	e.expires = now.Add(ttl)
+
+	return nil
+}
+
+// sweep removes expired entries, at most once a minute.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(time.Minute)
+
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
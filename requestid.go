@@ -0,0 +1,109 @@
+package webapp
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/mbict/go-webapp/decoder"
+	"github.com/mbict/go-webapp/internal"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID returns middleware that propagates the X-Request-ID header of the request, or assigns a new
+// id when there is none. The id is added to the response headers and stored in the context, where it can
+// be bound with `request:"id"`.
+func RequestID() Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = uuid.NewString()
+			}
+
+			rw.Header().Set(RequestIDHeader, id)
+			next(rw, req.WithContext(context.WithValue(req.Context(), decoder.RequestIDKey, id)))
+		}
+	}
+}
+
+// RequestIDFromContext returns the request id assigned by the RequestID middleware.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(decoder.RequestIDKey).(string)
+	return id
+}
+
+// validRequestID only accepts reasonably sized ids of printable ascii, so client supplied ids are safe to log.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLog returns middleware that writes a structured log entry for every request, also for requests that panic.
+// When logger is nil the logger of the API options is used, see WithLogger.
+func AccessLog(logger *slog.Logger) Middleware {
+	contexts := newHandlerContexts(nil)
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			info, req := WithRequestInfo(req)
+			w := internal.NewResponseWriter(rw)
+
+			//deferred, so a panic is logged on its way up
+			completed := false
+			defer func() {
+				l := logger
+				if l == nil {
+					l = contexts.of(req).getLogger()
+				}
+
+				status := w.StatusCode()
+				if !completed {
+					status = http.StatusInternalServerError
+				}
+
+				level := slog.LevelInfo
+				if status >= http.StatusInternalServerError {
+					level = slog.LevelError
+				}
+
+				attrs := []slog.Attr{
+					slog.String("method", req.Method),
+					slog.String("route", info.Route),
+					slog.String("path", req.URL.Path),
+					slog.Int("status", status),
+					slog.Int64("bytes", w.Bytes),
+					slog.Duration("latency", time.Since(start)),
+				}
+
+				if id := RequestIDFromContext(req.Context()); id != "" {
+					attrs = append(attrs, slog.String("request_id", id))
+				}
+
+				if info.Err != nil {
+					attrs = append(attrs, slog.String("error", info.Err.Error()))
+				}
+
+				if !completed {
+					attrs = append(attrs, slog.Bool("panic", true))
+				}
+
+				l.LogAttrs(req.Context(), level, "request", attrs...)
+			}()
+
+			next(w, req)
+			completed = true
+		}
+	}
+}
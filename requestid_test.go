@@ -0,0 +1,126 @@
+package webapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type requestIDRequest struct {
+	Id string `path:"id"`
+
+	RequestID string `request:"id"`
+}
+
+func TestRequestID(t *testing.T) {
+	api := New(nil)
+	api.Use(RequestID())
+	api.Get("/res/@id", H(func(ctx context.Context, req requestIDRequest) (string, error) {
+		return req.RequestID, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/res/1", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	assert.Equal(t, "abc-123", rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, "\"abc-123\"\n", rec.Body.String())
+
+	//invalid ids are replaced
+	req = httptest.NewRequest(http.MethodGet, "/res/1", nil)
+	req.Header.Set(RequestIDHeader, "abc 123")
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	id := rec.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 36)
+	assert.Equal(t, "\""+id+"\"\n", rec.Body.String())
+}
+
+func TestAccessLogAndErrorLogging(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	api := New(nil)
+	api.Use(RequestID(), AccessLog(logger))
+	api.Get("/res/@id", H(func(ctx context.Context, req requestIDRequest) (string, error) {
+		return "", Error(errors.New("database is down"), http.StatusServiceUnavailable)
+	}, DefaultOptions.Add(WithLogger(logger))...))
+
+	req := httptest.NewRequest(http.MethodGet, "/res/1", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	dec := json.NewDecoder(buf)
+
+	var errorEntry, accessEntry map[string]any
+	assert.NoError(t, dec.Decode(&errorEntry))
+	assert.NoError(t, dec.Decode(&accessEntry))
+
+	assert.Equal(t, "ERROR", errorEntry["level"])
+	assert.Equal(t, "request failed", errorEntry["msg"])
+	assert.Equal(t, "abc-123", errorEntry["request_id"])
+	assert.Equal(t, "database is down", errorEntry["error"])
+	assert.Equal(t, float64(503), errorEntry["status"])
+
+	assert.Equal(t, "request", accessEntry["msg"])
+	assert.Equal(t, "GET", accessEntry["method"])
+	assert.Equal(t, "/res/@id", accessEntry["route"])
+	assert.Equal(t, "/res/1", accessEntry["path"])
+	assert.Equal(t, float64(503), accessEntry["status"])
+	assert.Equal(t, float64(rec.Body.Len()), accessEntry["bytes"])
+	assert.Equal(t, "database is down", accessEntry["error"])
+	assert.Equal(t, "abc-123", accessEntry["request_id"])
+	assert.Contains(t, accessEntry, "latency")
+}
+
+func TestErrorLoggingReportsCause(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	cause := errors.New("connection refused")
+	h := ErrorHandler(DefaultOptions.Add(WithLogger(logger), WithErrorHandler(func(err error) error {
+		return ErrServiceUnavailable
+	}))...)(cause)
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var entry map[string]any
+	assert.NoError(t, json.NewDecoder(buf).Decode(&entry))
+	assert.Equal(t, "Service Unavailable", entry["error"])
+	assert.Equal(t, "connection refused", entry["cause"])
+}
+
+func TestAccessLogOptionsLoggerAndPanic(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	api := New(nil, DefaultOptions.Add(WithLogger(logger))...)
+	api.Use(AccessLog(nil))
+	api.Get("/panic", func(rw http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	})
+
+	assert.PanicsWithValue(t, "boom", func() {
+		api.RequestHander()(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+
+	var entry map[string]any
+	assert.NoError(t, json.NewDecoder(buf).Decode(&entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, "request", entry["msg"])
+	assert.Equal(t, "/panic", entry["route"])
+	assert.Equal(t, float64(500), entry["status"])
+	assert.Equal(t, true, entry["panic"])
+}
@@ -0,0 +1,80 @@
+package webapp
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestInfo collects how a request is handled, so logging and metrics middleware can report on it
+// after the handler returns.
+type RequestInfo struct {
+	// Route is the pattern of the matched route, e.g. /res/@id.
+	Route string
+
+	// Err is the error that was encoded as the response, if any.
+	Err error
+
+	// Failure tells in which stage a handler created with H failed.
+	Failure Failure
+}
+
+// Failure is the stage in which a handler failed.
+type Failure int
+
+const (
+	NoFailure Failure = iota
+	// DecodeFailure is a request that could not be decoded, a client side failure.
+	DecodeFailure
+	// AuthorizationFailure is a request denied by the Authorizer.
+	AuthorizationFailure
+	// HandlerFailure is an error returned by the handler.
+	HandlerFailure
+	// EncodeFailure is a response that could not be encoded, a server side failure.
+	EncodeFailure
+	// PanicFailure is a handler that panicked, a server side failure.
+	PanicFailure
+	// TimeoutFailure is a handler that overran its deadline, see WithTimeout.
+	TimeoutFailure
+)
+
+func (f Failure) String() string {
+	switch f {
+	case DecodeFailure:
+		return "decode"
+	case AuthorizationFailure:
+		return "authorization"
+	case HandlerFailure:
+		return "handler"
+	case EncodeFailure:
+		return "encode"
+	case PanicFailure:
+		return "panic"
+	case TimeoutFailure:
+		return "timeout"
+	}
+	return "none"
+}
+
+func setFailure(req *http.Request, failure Failure) {
+	if info := RequestInfoFromContext(req.Context()); info != nil {
+		info.Failure = failure
+	}
+}
+
+type requestInfoKey struct{}
+
+// RequestInfoFromContext returns the RequestInfo of the request, or nil if none is tracked.
+func RequestInfoFromContext(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
+
+// WithRequestInfo returns the RequestInfo of the request, adding a new one to the request when it has none.
+func WithRequestInfo(req *http.Request) (*RequestInfo, *http.Request) {
+	if info := RequestInfoFromContext(req.Context()); info != nil {
+		return info, req
+	}
+
+	info := &RequestInfo{}
+	return info, req.WithContext(context.WithValue(req.Context(), requestInfoKey{}, info))
+}
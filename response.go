@@ -26,6 +26,29 @@ func NewCreatedResponse(url string) CreatedResponse {
 	}
 }
 
+// AcceptedResponse responds with 202 Accepted, for requests that are processed asynchronously. The location points to
+// the resource reporting the status of the processing.
+type AcceptedResponse struct {
+	Empty
+	url string
+}
+
+func (c AcceptedResponse) StatusCode() int {
+	return http.StatusAccepted
+}
+
+func (c AcceptedResponse) Header() http.Header {
+	h := http.Header{}
+	h.Add("Location", c.url)
+	return h
+}
+
+func NewAcceptedResponse(url string) AcceptedResponse {
+	return AcceptedResponse{
+		url: url,
+	}
+}
+
 type empty interface {
 	emptyResponse() bool
 }
@@ -0,0 +1,56 @@
+package webapp
+
+import (
+	"github.com/justinas/alice"
+	"net/http"
+	"strings"
+)
+
+// Route describes a registered route.
+type Route struct {
+	Method string
+	// Host is the host pattern of the route, empty for the routes of every host.
+	Host string
+	// Path is the pattern as registered, including the types of the parameters.
+	Path        string
+	Params      []PathParam
+	Permissions []string
+	// Version is the version the route is registered for, empty for unversioned routes. A versioned route is listed
+	// once with the path it is registered with, it is also served under the path prefixes of the version.
+	Version string
+
+	mounted *mounted
+}
+
+// Routes returns all the registered routes in registration order.
+func (r *API) Routes() []Route {
+	res := make([]Route, 0, len(r.routes))
+	for _, route := range r.routes {
+		if m := route.mounted; m != nil {
+			prefix := strings.TrimSuffix(route.Path, "/*")
+			for _, sub := range m.api.Routes() {
+				if m.strip {
+					sub.Path = prefix + sub.Path
+				}
+				res = append(res, sub)
+			}
+			continue
+		}
+
+		res = append(res, *route)
+		res[len(res)-1].Params = append([]PathParam(nil), route.Params...)
+		res[len(res)-1].Permissions = append([]string(nil), route.Permissions...)
+	}
+	return res
+}
+
+// buildRoute composes the middleware chain for the route and describes the route with the permissions declared
+// in the middleware. Handlers not created with H get the required permissions evaluated before they are called.
+func buildRoute(route *Route, handle http.Handler, mw []Middleware) http.Handler {
+	route.Permissions = requiredPermissions(mw)
+	if !authorizesItself(handle) {
+		handle = authorizeRequest(handle)
+	}
+
+	return alice.New(mc(mw)...).Then(handle)
+}
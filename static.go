@@ -0,0 +1,275 @@
+package webapp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/mbict/httprouter"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type staticConfig struct {
+	index        string
+	spaFallback  bool
+	cacheControl func(name string) string
+}
+
+// StaticOption configures how static files are served.
+type StaticOption func(*staticConfig)
+
+// WithIndex sets the file served for a directory, defaults to index.html.
+func WithIndex(name string) StaticOption {
+	return func(c *staticConfig) {
+		c.index = name
+	}
+}
+
+// SPAFallback serves the index file of the root for paths without a file extension that do not exist, so the client side
+// router of a single page application can handle them. Missing assets, paths with an extension, still return a 404.
+func SPAFallback() StaticOption {
+	return func(c *staticConfig) {
+		c.spaFallback = true
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of the served files by name. By default fingerprinted files, like
+// app.3f2a9c1b.js, are cached for a year and other files are revalidated with their ETag.
+func WithCacheControl(cacheControl func(name string) string) StaticOption {
+	return func(c *staticConfig) {
+		c.cacheControl = cacheControl
+	}
+}
+
+var fingerprint = regexp.MustCompile(`[.-][0-9a-fA-F]{8,}\.[^./]+$`)
+
+func defaultCacheControl(name string) string {
+	if fingerprint.MatchString(name) {
+		return "public, max-age=31536000, immutable"
+	}
+	return "no-cache"
+}
+
+// staticHandler serves the files of the file system, the path is the catch-all parameter filepath.
+type staticHandler struct {
+	api    *API
+	fsys   fs.FS
+	config *staticConfig
+	etags  sync.Map
+}
+
+// etagKey identifies a version of a file, the ETag is computed once per version.
+type etagKey struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// Static serves the files of fsys under the prefix, from disk with os.DirFS or embedded with embed.FS. Files are
+// served with their content type, support Range requests and conditional requests with an ETag, and a .gz variant
+// is served to clients that accept gzip. Directories serve their index file, files that do not exist are handled
+// by API.NotFound.
+//
+//	//go:embed assets
+//	var assets embed.FS
+//
+//	sub, _ := fs.Sub(assets, "assets")
+//	api.Static("/assets", sub)
+func (r *API) Static(prefix string, fsys fs.FS, options ...StaticOption) {
+	registerStatic(r, r, prefix, fsys, options)
+}
+
+func registerStatic(router Router, api *API, prefix string, fsys fs.FS, options []StaticOption) {
+	cfg := &staticConfig{index: "index.html", cacheControl: defaultCacheControl}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	h := &staticHandler{api: api, fsys: fsys, config: cfg}
+	pattern := strings.TrimSuffix(prefix, "/") + "/*filepath"
+	router.Handler(http.MethodGet, pattern, h)
+	router.Handler(http.MethodHead, pattern, h)
+}
+
+// apiOf returns the API the router registers its routes on, nil for other router implementations.
+func apiOf(r Router) *API {
+	switch r := r.(type) {
+	case *API:
+		return r
+	case *host:
+		return r.r
+	case *versioned:
+		return r.r
+	case *group:
+		return apiOf(r.r)
+	}
+	return nil
+}
+
+func (h *staticHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	p := httprouter.ParamsFromContext(req.Context()).ByName("filepath")
+	name := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if name == "" {
+		name = "."
+	}
+
+	if !fs.ValidPath(name) {
+		h.notFound(rw, req)
+		return
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	switch {
+	case err != nil && h.config.spaFallback && path.Ext(name) == "":
+		name = h.config.index
+	case err != nil:
+		h.notFound(rw, req)
+		return
+	case info.IsDir():
+		//relative links of the index need the trailing slash
+		if !strings.HasSuffix(req.URL.Path, "/") {
+			//the path of a mounted API is stripped of its prefix
+			prefix := MountPrefix(req.Context())
+			u := *req.URL
+			u.Path = prefix + u.Path + "/"
+			if u.RawPath != "" {
+				u.RawPath = prefix + u.RawPath + "/"
+			}
+			http.Redirect(rw, req, u.String(), http.StatusMovedPermanently)
+			return
+		}
+		name = path.Join(name, h.config.index)
+	}
+
+	if !h.serveFile(rw, req, name) {
+		h.notFound(rw, req)
+	}
+}
+
+// notFound handles missing files with API.NotFound, routers without an API respond with http.NotFound.
+func (h *staticHandler) notFound(rw http.ResponseWriter, req *http.Request) {
+	if h.api == nil || h.api.NotFound == nil {
+		http.NotFound(rw, req)
+		return
+	}
+	h.api.NotFound(rw, req)
+}
+
+// serveFile serves the file, or its .gz variant when the client accepts it, and reports if the file exists.
+func (h *staticHandler) serveFile(rw http.ResponseWriter, req *http.Request, name string) bool {
+	ctype := mime.TypeByExtension(path.Ext(name))
+
+	rw.Header().Add("Vary", "Accept-Encoding")
+	if acceptsGzip(req) {
+		if f, info, ok := h.open(name + ".gz"); ok {
+			defer f.Close()
+
+			if ctype == "" {
+				ctype = "application/octet-stream"
+			}
+			rw.Header().Set("Content-Encoding", "gzip")
+			h.serveContent(rw, req, name, ctype, f, info)
+			return true
+		}
+	}
+
+	f, info, ok := h.open(name)
+	if !ok {
+		return false
+	}
+	defer f.Close()
+
+	h.serveContent(rw, req, name, ctype, f, info)
+	return true
+}
+
+func (h *staticHandler) serveContent(rw http.ResponseWriter, req *http.Request, name string, ctype string, f fs.File, info fs.FileInfo) {
+	content, err := seeker(f)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	etag, err := h.etag(name, info, content)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if ctype != "" {
+		rw.Header().Set("Content-Type", ctype)
+	}
+	rw.Header().Set("ETag", etag)
+	if cc := h.config.cacheControl(name); cc != "" {
+		rw.Header().Set("Cache-Control", cc)
+	}
+
+	http.ServeContent(rw, req, name, info.ModTime(), content)
+}
+
+func (h *staticHandler) open(name string) (fs.File, fs.FileInfo, bool) {
+	f, err := h.fsys.Open(name)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		f.Close()
+		return nil, nil, false
+	}
+	return f, info, true
+}
+
+// etag returns the strong ETag of the content, it is computed once for every version of the file.
+func (h *staticHandler) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := etagKey{name: name, size: info.Size(), modTime: info.ModTime()}
+	if etag, ok := h.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+
+	hash := fnv.New64a()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+	h.etags.Store(key, etag)
+	return etag, nil
+}
+
+// seeker returns the file as io.ReadSeeker, files of a file system without seek support are read into memory.
+func seeker(f fs.File) (io.ReadSeeker, error) {
+	if rs, ok := f.(io.ReadSeeker); ok {
+		return rs, nil
+	}
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+func acceptsGzip(req *http.Request) bool {
+	for _, value := range req.Header.Values("Accept-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			encoding, q, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+			if strings.EqualFold(strings.TrimSpace(encoding), "gzip") && strings.ReplaceAll(q, " ", "") != "q=0" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package webapp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func gzipped(s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+func staticFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":          {Data: []byte("<html>root</html>")},
+		"app.3f2a9c1b.js":     {Data: []byte("console.log('app')")},
+		"app.3f2a9c1b.js.gz":  {Data: gzipped("console.log('app')")},
+		"style.css":           {Data: []byte("body{}")},
+		"docs/index.html":     {Data: []byte("<html>docs</html>")},
+		"images/logo.svg":     {Data: []byte("<svg></svg>")},
+		"data/numbers.txt":    {Data: []byte("0123456789")},
+		"empty/.keep":         {Data: []byte("")},
+		"nested/deep/file.md": {Data: []byte("# deep")},
+	}
+}
+
+func TestStatic(t *testing.T) {
+	api := New(nil)
+	api.NotFound = func(rw http.ResponseWriter, req *http.Request) {
+		http.Error(rw, "custom not found", http.StatusNotFound)
+	}
+	api.Static("/assets", staticFS())
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	rec := serve(httptest.NewRequest(http.MethodGet, "/assets/style.css", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "body{}", rec.Body.String())
+	assert.Equal(t, "text/css; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	//conditional request with the etag
+	req := httptest.NewRequest(http.MethodGet, "/assets/style.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, serve(req).Code)
+
+	//range request
+	req = httptest.NewRequest(http.MethodGet, "/assets/data/numbers.txt", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	rec = serve(req)
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "234", rec.Body.String())
+
+	//fingerprinted assets are cached long, precompressed variants are served when accepted
+	req = httptest.NewRequest(http.MethodGet, "/assets/app.3f2a9c1b.js", nil)
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	rec = serve(req)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "public, max-age=31536000, immutable", rec.Header().Get("Cache-Control"))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "javascript")
+	assert.Equal(t, gzipped("console.log('app')"), rec.Body.Bytes())
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/assets/app.3f2a9c1b.js", nil))
+	assert.Equal(t, "", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "console.log('app')", rec.Body.String())
+
+	//directory index
+	assert.Equal(t, "<html>docs</html>", serve(httptest.NewRequest(http.MethodGet, "/assets/docs/", nil)).Body.String())
+	assert.Equal(t, "<html>root</html>", serve(httptest.NewRequest(http.MethodGet, "/assets/", nil)).Body.String())
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/assets/docs?v=1", nil))
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/assets/docs/?v=1", rec.Header().Get("Location"))
+
+	//missing files and directories without index go through NotFound
+	rec = serve(httptest.NewRequest(http.MethodGet, "/assets/missing.css", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "custom not found\n", rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, serve(httptest.NewRequest(http.MethodGet, "/assets/empty/", nil)).Code)
+
+	//path traversal stays within the file system
+	assert.Equal(t, http.StatusNotFound, serve(httptest.NewRequest(http.MethodGet, "/assets/../../etc/passwd", nil)).Code)
+
+	rec = serve(httptest.NewRequest(http.MethodHead, "/assets/style.css", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "", rec.Body.String())
+}
+
+func TestStaticSPAFallback(t *testing.T) {
+	api := New(nil)
+	api.Group("/app").Static("/", staticFS(), SPAFallback(), WithCacheControl(func(name string) string { return "" }))
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := serve("/app/users/42")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "<html>root</html>", rec.Body.String())
+	assert.Equal(t, "", rec.Header().Get("Cache-Control"))
+
+	assert.Equal(t, http.StatusNotFound, serve("/app/missing.js").Code)
+	assert.Equal(t, "# deep", serve("/app/nested/deep/file.md").Body.String())
+}
+
+func TestStaticMounted(t *testing.T) {
+	site := New(nil)
+	site.Static("/assets", staticFS())
+
+	api := New(nil)
+	api.Mount("/site", site)
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/site/assets/docs?v=1", nil))
+
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/site/assets/docs/?v=1", rec.Header().Get("Location"))
+}
+
+// customRouter is a router implementation of another package, its API is unknown.
+type customRouter struct {
+	Router
+}
+
+func TestStaticCustomRouter(t *testing.T) {
+	api := New(nil)
+	g := &group{r: customRouter{Router: api}, prefix: "/app"}
+	g.Static("/", staticFS())
+
+	rec := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/app/missing.js", nil))
+	})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,128 @@
+package webapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// TimeoutHeader is the header a client can lower the timeout of a request with, in seconds, e.g. 1.5, or as a
+// duration, e.g. 1500ms. It is only honored on handlers with a timeout, see WithTimeout.
+const TimeoutHeader = "X-Request-Timeout"
+
+// statusClientClosedRequest is the non-standard status of a request the client canceled before the response.
+const statusClientClosedRequest = 499
+
+// errClientClosedRequest replaces the error of a handler when the client went away, the response is never read so this
+// is not a failure of the server.
+var errClientClosedRequest = &HTTPError{context.Canceled, statusClientClosedRequest}
+
+// WithTimeout sets the timeout of the handler. The handler is called with a context that has the deadline of the
+// timeout, when it overruns the deadline 503 Service Unavailable is returned and its result is discarded.
+// When the deadline is set by the TimeoutHeader of the client 504 Gateway Timeout is returned instead.
+func WithTimeout(timeout time.Duration) Option {
+	return func(ctx *HandlerContext) {
+		ctx.timeout = timeout
+	}
+}
+
+// requestTimeout returns the timeout of the handler, lowered by the TimeoutHeader of the request. A handler without a
+// timeout ignores the header, so a client cannot make the server run handlers in a goroutine of their own.
+// Budget tells if the timeout is the one of the client.
+func (ctx *HandlerContext) requestTimeout(req *http.Request) (timeout time.Duration, budget bool) {
+	timeout = ctx.timeout
+	if timeout <= 0 {
+		return 0, false
+	}
+	if d, ok := parseTimeout(req.Header.Get(TimeoutHeader)); ok && d < timeout {
+		return d, true
+	}
+	return timeout, false
+}
+
+func parseTimeout(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		s, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false
+		}
+		d = time.Duration(s * float64(time.Second))
+	}
+	return d, d > 0
+}
+
+// handleWithDeadline calls the handle in its own goroutine and returns once it returns or the context is done,
+// whatever comes first. The result of a handle that overruns the deadline is discarded, so it never writes the
+// response after the timeout response is written, a panic after the deadline is passed to late with its stack.
+// TimedOut is only reported for the deadline, a canceled parent context is returned as the error.
+func handleWithDeadline[T any, O any](ctx context.Context, handle Handle[T, O], request T, late func(recovered any, stack []byte)) (res O, err error, timedOut bool) {
+	type result struct {
+		res       O
+		err       error
+		recovered any
+		stack     []byte
+		panicked  bool
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		r := result{panicked: true}
+		defer func() {
+			if r.panicked {
+				r.recovered = recover()
+				r.stack = debug.Stack()
+			}
+			done <- r
+		}()
+		r.res, r.err = handle(ctx, request)
+		r.panicked = false
+	}()
+
+	select {
+	case r := <-done:
+		//a panic of the handle is raised in the goroutine of the request, with the stack of the handle as that is lost
+		if r.panicked {
+			if r.recovered == http.ErrAbortHandler {
+				panic(r.recovered)
+			}
+			panic(&handlerPanic{value: r.recovered, stack: r.stack})
+		}
+
+		//a handle returning at the deadline has timed out, whatever it returned
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return res, ctx.Err(), true
+		}
+		return r.res, r.err, false
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.panicked {
+				late(r.recovered, r.stack)
+			}
+		}()
+		return res, ctx.Err(), errors.Is(ctx.Err(), context.DeadlineExceeded)
+	}
+}
+
+// handlerPanic is a panic of a handle that ran in its own goroutine, raised again in the goroutine of the request.
+type handlerPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *handlerPanic) Error() string {
+	return fmt.Sprintf("%v\n\ngoroutine of the handler:\n%s", p.value, p.stack)
+}
+
+func (p *handlerPanic) Unwrap() error {
+	err, _ := p.value.(error)
+	return err
+}
@@ -0,0 +1,199 @@
+package webapp
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	slow := H(func(ctx context.Context, req Empty) (string, error) {
+		<-release
+		return "late", nil
+	})
+
+	api := New(nil, WithTimeout(time.Hour))
+	api.Get("/slow", slow, UseOptions(WithTimeout(10*time.Millisecond)))
+	api.Get("/budget", slow)
+	api.Get("/deadline", H(func(ctx context.Context, req struct {
+		Deadline time.Time `request:"deadline"`
+	}) (bool, error) {
+		deadline, _ := ctx.Deadline()
+		return deadline.Equal(req.Deadline), nil
+	}))
+
+	serve := func(target string, budget string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if budget != "" {
+			req.Header.Set(TimeoutHeader, budget)
+		}
+		info, req := WithRequestInfo(req)
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		if rec.Code != http.StatusOK {
+			assert.Equal(t, TimeoutFailure, info.Failure)
+		}
+		return rec
+	}
+
+	//the route overruns its timeout
+	rec := serve("/slow", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "{\"message\":\"Service Unavailable\"}\n", rec.Body.String())
+
+	//a client cannot raise the timeout
+	rec = serve("/slow", "1h")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	//but can lower it
+	rec = serve("/budget", "0.01")
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+
+	rec = serve("/deadline", "")
+	assert.Equal(t, "true\n", rec.Body.String())
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	api := New(nil, WithTimeout(time.Second))
+	api.Get("/panic", H(func(ctx context.Context, req Empty) (string, error) {
+		panic("boom")
+	}))
+
+	defer func() {
+		//the panic is raised again with the stack of the handler
+		p, ok := recover().(*handlerPanic)
+		assert.True(t, ok)
+		assert.Equal(t, "boom", p.value)
+		assert.Contains(t, p.Error(), "TestTimeoutPanic")
+	}()
+	api.RequestHander()(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	t.Fatal("the panic is not raised again")
+}
+
+func TestTimeoutContextAware(t *testing.T) {
+	api := New(nil, WithTimeout(10*time.Millisecond))
+	api.Get("/aware", H(func(ctx context.Context, req Empty) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+
+	//the handler returning the deadline error is a timeout, not a failure of the handler
+	for i := 0; i < 10; i++ {
+		info, req := WithRequestInfo(httptest.NewRequest(http.MethodGet, "/aware", nil))
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, TimeoutFailure, info.Failure)
+	}
+}
+
+func TestTimeoutCanceledParent(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	api := New(nil, WithTimeout(time.Hour))
+	api.Get("/slow", H(func(ctx context.Context, req Empty) (string, error) {
+		close(started)
+		<-release
+		return "late", nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	info, req := WithRequestInfo(httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx))
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	//a client that went away is not a timeout nor a server error
+	assert.Equal(t, statusClientClosedRequest, rec.Code)
+	assert.Equal(t, HandlerFailure, info.Failure)
+	assert.ErrorIs(t, info.Err, context.Canceled)
+}
+
+func TestCanceledRequest(t *testing.T) {
+	buf := &syncBuffer{}
+	api := New(nil, WithLogger(slog.New(slog.NewJSONHandler(buf, nil))))
+	api.Get("/aware", H(func(ctx context.Context, req Empty) (string, error) {
+		return "", ctx.Err()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/aware", nil).WithContext(ctx))
+
+	assert.Equal(t, statusClientClosedRequest, rec.Code)
+	assert.NotContains(t, buf.String(), `"level":"ERROR"`)
+}
+
+func TestTimeoutHeaderWithoutTimeout(t *testing.T) {
+	api := New(nil)
+	api.Get("/deadline", H(func(ctx context.Context, req Empty) (bool, error) {
+		_, ok := ctx.Deadline()
+		return ok, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/deadline", nil)
+	req.Header.Set(TimeoutHeader, "1s")
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	//the header only lowers the timeout of the server
+	assert.Equal(t, "false\n", rec.Body.String())
+}
+
+// syncBuffer is a buffer that can be written by the goroutine of a handler that overran its deadline.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTimeoutLatePanic(t *testing.T) {
+	release := make(chan struct{})
+	buf := &syncBuffer{}
+
+	api := New(nil, WithTimeout(10*time.Millisecond), WithLogger(slog.New(slog.NewJSONHandler(buf, nil))))
+	api.Get("/panic", H(func(ctx context.Context, req Empty) (string, error) {
+		<-release
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), `"msg":"handler panicked after the deadline"`) &&
+			strings.Contains(buf.String(), `"panic":"boom"`)
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,148 @@
+package webapp
+
+import (
+	"context"
+	"github.com/mbict/go-webapp/internal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"net/http"
+)
+
+const tracerName = "github.com/mbict/go-webapp"
+
+// traceContext propagates the W3C traceparent and tracestate headers.
+var traceContext = propagation.TraceContext{}
+
+// WithTracerProvider enables tracing with the provider, pass it to New to create a span for every request, it is only
+// accepted by New.
+// The span is named by the route pattern, handlers created with H add child spans for decoding, the handler
+// call and encoding.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(ctx *HandlerContext) {
+		ctx.tracerProvider = provider
+	}
+}
+
+// tracing returns middleware that starts the server span of the request, continuing the trace of the
+// inbound traceparent header.
+func tracing(provider trace.TracerProvider) Middleware {
+	tracer := provider.Tracer(tracerName)
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(rw http.ResponseWriter, req *http.Request) {
+			ctx := traceContext.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			ctx, span := tracer.Start(ctx, "HTTP "+req.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("url.path", req.URL.Path),
+				),
+			)
+			defer span.End()
+
+			info, req := WithRequestInfo(req.WithContext(ctx))
+			w := internal.NewResponseWriter(rw)
+
+			//deferred, so panics end up in the span on their way up
+			defer func() {
+				if info.Route != "" {
+					span.SetName(req.Method + " " + info.Route)
+					span.SetAttributes(attribute.String("http.route", info.Route))
+				}
+
+				status := w.StatusCode()
+				if info.Failure == PanicFailure {
+					status = http.StatusInternalServerError
+				}
+				span.SetAttributes(attribute.Int("http.response.status_code", status))
+
+				if status >= http.StatusInternalServerError {
+					if info.Err != nil {
+						span.RecordError(info.Err)
+					}
+					span.SetStatus(codes.Error, http.StatusText(status))
+				}
+			}()
+
+			next(w, req)
+		}
+	}
+}
+
+// startSpan starts a child span of the span in the request context. Untraced requests get a no-op span
+// and the request itself back.
+func startSpan(req *http.Request, name string) (*http.Request, trace.Span) {
+	parent := trace.SpanFromContext(req.Context())
+	if !parent.IsRecording() {
+		return req, noop.Span{}
+	}
+
+	ctx, span := parent.TracerProvider().Tracer(tracerName).Start(req.Context(), name)
+	return req.WithContext(ctx), span
+}
+
+// endSpan ends the span, marking it as failed when an error occurred.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceTransport returns a http.RoundTripper that creates a client span for outbound requests and propagates
+// the trace of the request context with the W3C traceparent header. A nil base uses http.DefaultTransport.
+//
+//	client := &http.Client{Transport: webapp.TraceTransport(nil)}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com", nil)
+func TraceTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &traceTransport{base: base}
+}
+
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	var span trace.Span = noop.Span{}
+	if parent := trace.SpanFromContext(ctx); parent.IsRecording() {
+		ctx, span = parent.TracerProvider().Tracer(tracerName).Start(ctx, "HTTP "+req.Method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.request.method", req.Method),
+				attribute.String("url.full", req.URL.String()),
+			),
+		)
+	}
+	defer span.End()
+
+	//a round tripper should not modify the request
+	req = req.Clone(ctx)
+	InjectTraceContext(ctx, req.Header)
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+	if res.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+	}
+	return res, nil
+}
+
+// InjectTraceContext adds the traceparent header of the span in the context to the headers of an outbound request.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	traceContext.Inject(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,133 @@
+package webapp
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type tracingRequest struct {
+	Id    string `path:"id"`
+	Limit int    `query:"limit" default:"10"`
+	Name  string `json:"name"`
+}
+
+func newTracedAPI() (*API, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return New(nil, WithTracerProvider(provider)), recorder
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+func TestTracing(t *testing.T) {
+	api, recorder := newTracedAPI()
+	api.Post("/res/@id", H(func(ctx context.Context, req tracingRequest) (string, error) {
+		assert.True(t, trace.SpanFromContext(ctx).IsRecording())
+		return req.Id, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/res/1", strings.NewReader(`{"name":"test"}`))
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	spans := recorder.Ended()
+	assert.Equal(t, []string{"decode defaults", "decode body", "decode query", "decode path", "decode", "handle", "encode", "POST /res/@id"}, spanNames(spans))
+
+	server := spans[len(spans)-1]
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Contains(t, server.Attributes(), attribute.String("http.route", "/res/@id"))
+
+	//child spans
+	decode := spans[4]
+	assert.Equal(t, server.SpanContext().SpanID(), decode.Parent().SpanID())
+	assert.Equal(t, decode.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, server.SpanContext().SpanID(), spans[5].Parent().SpanID())
+}
+
+func TestTracingErrors(t *testing.T) {
+	api, recorder := newTracedAPI()
+	api.Get("/res/@id", H(func(ctx context.Context, req tracingRequest) (string, error) {
+		return "", errors.New("database is down")
+	}))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/res/1?limit=abc", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/res/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	spans := recorder.Ended()
+	names := spanNames(spans)
+
+	//decode failure, the server span is not failed for client errors
+	assert.Equal(t, []string{"decode defaults", "decode query", "decode", "GET /res/@id"}, names[:4])
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, codes.Unset, spans[3].Status().Code)
+
+	//handler failure
+	assert.Equal(t, "handle", names[8])
+	assert.Equal(t, codes.Error, spans[8].Status().Code)
+	assert.Equal(t, codes.Error, spans[9].Status().Code)
+}
+
+func TestTracingUnmatchedRoute(t *testing.T) {
+	api, recorder := newTracedAPI()
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	assert.Equal(t, []string{"HTTP GET"}, spanNames(recorder.Ended()))
+}
+
+func TestTraceTransport(t *testing.T) {
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		traceparent = req.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: TraceTransport(nil)}
+
+	api, recorder := newTracedAPI()
+	api.Get("/proxy", H(func(ctx context.Context, req Empty) (*Empty, error) {
+		out, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+		res, err := client.Do(out)
+		if err != nil {
+			return nil, err
+		}
+		return nil, res.Body.Close()
+	}))
+
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, httptest.NewRequest(http.MethodGet, "/proxy", nil))
+
+	spans := recorder.Ended()
+	assert.Equal(t, []string{"decode", "HTTP GET", "handle", "GET /proxy"}, spanNames(spans))
+
+	outbound := spans[1]
+	assert.Equal(t, trace.SpanKindClient, outbound.SpanKind())
+	assert.Equal(t, "00-"+outbound.SpanContext().TraceID().String()+"-"+outbound.SpanContext().SpanID().String()+"-01", traceparent)
+}
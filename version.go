@@ -0,0 +1,245 @@
+package webapp
+
+import (
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionSource selects the version a request asks for.
+type VersionSource struct {
+	// prefix registers the versioned routes under the prefix followed by the version, /v2/users.
+	prefix string
+
+	// version returns the requested version, empty when the request does not ask for one.
+	version func(req *http.Request) string
+}
+
+// VersionPath registers the routes of a version under the prefix followed by the version, VersionPath("/v") serves
+// version 2 of /users at /v2/users.
+func VersionPath(prefix string) VersionSource {
+	return VersionSource{prefix: prefix}
+}
+
+// VersionHeader selects the version with the value of the header, e.g. Api-Version: 2.
+func VersionHeader(name string) VersionSource {
+	return VersionSource{version: func(req *http.Request) string {
+		return req.Header.Get(name)
+	}}
+}
+
+// VersionMediaType selects the version with the version parameter of the Accept header,
+// Accept: application/vnd.acme+json; version=2.
+func VersionMediaType() VersionSource {
+	return VersionSource{version: func(req *http.Request) string {
+		return MediaTypeParam(req.Header.Get("Accept"), "version")
+	}}
+}
+
+// defaultVersionSources are used when the API is created without WithVersioning.
+var defaultVersionSources = []VersionSource{VersionPath("/v"), VersionHeader("Api-Version"), VersionMediaType()}
+
+type versionConfig struct {
+	deprecated bool
+	sunset     time.Time
+}
+
+// VersionOption configures a version.
+type VersionOption func(*versionConfig)
+
+// Deprecated marks the version as deprecated, its responses have the Deprecation header and the Sunset header
+// when a sunset date is given.
+func Deprecated(sunset time.Time) VersionOption {
+	return func(c *versionConfig) {
+		c.deprecated = true
+		c.sunset = sunset
+	}
+}
+
+// versionDispatch serves a route with the handler of the requested version.
+type versionDispatch struct {
+	api      *API
+	handlers map[string]http.Handler
+	latest   string
+}
+
+func (d *versionDispatch) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	version := d.api.requestedVersion(req)
+	if version == "" {
+		version = d.api.handlerCtx.defaultVersion
+	}
+	if version == "" {
+		version = d.latest
+	}
+
+	h, ok := d.handlers[version]
+	if !ok {
+		d.api.NotFound(rw, req)
+		return
+	}
+	h.ServeHTTP(rw, req)
+}
+
+func (r *API) requestedVersion(req *http.Request) string {
+	for _, source := range r.versionSources() {
+		if source.version != nil {
+			if version := source.version(req); version != "" {
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+func (r *API) versionSources() []VersionSource {
+	if r.handlerCtx.versionSources != nil {
+		return r.handlerCtx.versionSources
+	}
+	return defaultVersionSources
+}
+
+// Version returns the router for the routes of the version. The same route can be registered for several versions,
+// the version is selected by the sources set with WithVersioning, by default the path prefix /v2, the Api-Version header
+// and the version parameter of the Accept header. Requests without a version are served by the default version of
+// WithDefaultVersion, or else the latest version of the route.
+//
+//	api.Version("1", Deprecated(sunset)).Get("/users", H(listUsersV1))
+//	api.Version("2").Get("/users", H(listUsers))
+func (r *API) Version(version string, options ...VersionOption) Router {
+	r.checkModifiable()
+
+	cfg, ok := r.versions[version]
+	if !ok {
+		cfg = &versionConfig{}
+		r.versions[version] = cfg
+	}
+
+	for _, option := range options {
+		option(cfg)
+	}
+	return &versioned{r: r, version: version, config: cfg}
+}
+
+// versioned is the Router of a version.
+type versioned struct {
+	r       *API
+	version string
+	config  *versionConfig
+}
+
+func (v *versioned) Get(path string, handle http.HandlerFunc, mw ...Middleware) {
+	v.Handler(http.MethodGet, path, handle, mw...)
+}
+
+func (v *versioned) Post(path string, handle http.HandlerFunc, mw ...Middleware) {
+	v.Handler(http.MethodPost, path, handle, mw...)
+}
+
+func (v *versioned) Put(path string, handle http.HandlerFunc, mw ...Middleware) {
+	v.Handler(http.MethodPut, path, handle, mw...)
+}
+
+func (v *versioned) Patch(path string, handle http.HandlerFunc, mw ...Middleware) {
+	v.Handler(http.MethodPatch, path, handle, mw...)
+}
+
+func (v *versioned) Delete(path string, handle http.HandlerFunc, mw ...Middleware) {
+	v.Handler(http.MethodDelete, path, handle, mw...)
+}
+
+func (v *versioned) Handle(method, path string, handle http.HandlerFunc, mw ...Middleware) {
+	v.Handler(method, path, handle, mw...)
+}
+
+// Handler builds the route once, it is served under the path prefixes of the version and by the dispatcher of the
+// route that selects the handler of the requested version.
+func (v *versioned) Handler(method, path string, handle http.Handler, mw ...Middleware) {
+	mw = append([]Middleware{v.deprecation}, mw...)
+	pattern, h := v.r.build(&Route{Method: method, Path: path, Version: v.version}, handle, mw)
+
+	dispatch := false
+	for _, source := range v.r.versionSources() {
+		if source.prefix != "" {
+			v.r.router.Handler(method, source.prefix+v.version+pattern, h)
+		}
+		dispatch = dispatch || source.version != nil
+	}
+
+	if !dispatch {
+		return
+	}
+
+	key := method + " " + pattern
+	d, ok := v.r.dispatchers[key]
+	if !ok {
+		d = &versionDispatch{api: v.r, handlers: map[string]http.Handler{}}
+		v.r.dispatchers[key] = d
+		v.r.router.Handler(method, pattern, d)
+	}
+
+	d.handlers[v.version] = h
+	if d.latest == "" || newerVersion(v.version, d.latest) {
+		d.latest = v.version
+	}
+}
+
+func (v *versioned) Group(path string, mw ...Middleware) Router {
+	return &group{prefix: path, r: v, middleware: mw}
+}
+
+func (v *versioned) Static(prefix string, fsys fs.FS, options ...StaticOption) {
+	registerStatic(v, v.r, prefix, fsys, options)
+}
+
+// deprecation is the middleware of the routes of the version, it adds the Deprecation and Sunset headers to the
+// responses of a deprecated version.
+func (v *versioned) deprecation(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if v.config.deprecated {
+			rw.Header().Set("Deprecation", "true")
+			if !v.config.sunset.IsZero() {
+				rw.Header().Set("Sunset", v.config.sunset.UTC().Format(http.TimeFormat))
+			}
+		}
+		next(rw, req)
+	}
+}
+
+// newerVersion reports if version a is newer than b, numeric versions like 1.10 are compared segment by segment.
+func newerVersion(a, b string) bool {
+	na, okA := versionSegments(a)
+	nb, okB := versionSegments(b)
+	if !okA || !okB {
+		return a > b
+	}
+
+	for i := 0; i < len(na) || i < len(nb); i++ {
+		var sa, sb int
+		if i < len(na) {
+			sa = na[i]
+		}
+		if i < len(nb) {
+			sb = nb[i]
+		}
+		if sa != sb {
+			return sa > sb
+		}
+	}
+	return false
+}
+
+// versionSegments returns the numeric segments of a dotted version, it reports false for other versions.
+func versionSegments(version string) ([]int, bool) {
+	parts := strings.Split(version, ".")
+	res := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		res[i] = n
+	}
+	return res, true
+}
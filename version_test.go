@@ -0,0 +1,150 @@
+package webapp
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func write(s string) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(s))
+	}
+}
+
+func TestVersioning(t *testing.T) {
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	api := New(nil)
+	api.Version("1", Deprecated(sunset)).Get("/users", write("v1"))
+	api.Version("2").Group("/users").Get("", write("v2"))
+	api.Version("10").Get("/users", write("v10"))
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	//path prefix
+	rec := serve(httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	assert.Equal(t, "v1", rec.Body.String())
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+
+	rec = serve(httptest.NewRequest(http.MethodGet, "/v2/users", nil))
+	assert.Equal(t, "v2", rec.Body.String())
+	assert.Equal(t, "", rec.Header().Get("Deprecation"))
+
+	//header
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Api-Version", "2")
+	assert.Equal(t, "v2", serve(req).Body.String())
+
+	//media type parameter
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Accept", "application/vnd.acme+json; version=1")
+	rec = serve(req)
+	assert.Equal(t, "v1", rec.Body.String())
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+
+	//latest version without a requested version, unknown versions are not found
+	assert.Equal(t, "v10", serve(httptest.NewRequest(http.MethodGet, "/users", nil)).Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Api-Version", "3")
+	assert.Equal(t, http.StatusNotFound, serve(req).Code)
+
+	versions := []string{}
+	for _, route := range api.Routes() {
+		versions = append(versions, route.Version+" "+route.Path)
+	}
+	assert.Equal(t, []string{"1 /users", "2 /users", "10 /users"}, versions)
+}
+
+func TestVersioningPolicy(t *testing.T) {
+	type deleteUser struct {
+		Id    string `path:"id"`
+		Owner string `header:"X-Owner"`
+	}
+
+	var seen []any
+	api := New(nil)
+	api.Authorizer = Policy(func(ctx context.Context, permissions []string, req deleteUser) error {
+		seen = append(seen, req)
+		if req.Owner != "me" {
+			return ErrForbidden
+		}
+		return nil
+	})
+	api.Version("1", Deprecated(time.Time{})).Delete("/users/@id", H(func(ctx context.Context, req deleteUser) (*Empty, error) {
+		return nil, nil
+	}), Require("users:delete"))
+
+	for _, target := range []string{"/v1/users/1", "/users/1"} {
+		seen = nil
+		req := httptest.NewRequest(http.MethodDelete, target, nil)
+		req.Header.Set("X-Owner", "me")
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code, target)
+		assert.Equal(t, "true", rec.Header().Get("Deprecation"), target)
+		assert.Equal(t, []any{deleteUser{Id: "1", Owner: "me"}}, seen, target)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/v1/users/1", nil)
+	req.Header.Set("X-Owner", "someone else")
+	rec := httptest.NewRecorder()
+	api.RequestHander()(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestVersioningSources(t *testing.T) {
+	api := New(nil, WithVersioning(VersionHeader("X-Version")), WithDefaultVersion("1"))
+	api.Version("1").Get("/users", write("v1"))
+	api.Version("2").Get("/users", write("v2"))
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.RequestHander()(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, "v1", serve(httptest.NewRequest(http.MethodGet, "/users", nil)).Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("X-Version", "2")
+	assert.Equal(t, "v2", serve(req).Body.String())
+
+	//no path prefix routes
+	assert.Equal(t, http.StatusNotFound, serve(httptest.NewRequest(http.MethodGet, "/v2/users", nil)).Code)
+}
+
+func TestNegotiatorVendorMediaType(t *testing.T) {
+	n := NewNegotiatorBuilder[string]()
+	n.Register("application/json", "json", "*/*")
+
+	v, err := n.Get("application/vnd.acme+json; version=2")
+	assert.NoError(t, err)
+	assert.Equal(t, "json", v)
+
+	_, err = n.Get("application/vnd.acme+xml")
+	assert.ErrorIs(t, err, ErrNotAcceptable)
+
+	assert.Equal(t, "2", MediaTypeParam("text/html, application/vnd.acme+json; version=2", "version"))
+}
+
+func TestNewerVersion(t *testing.T) {
+	assert.True(t, newerVersion("1.10", "1.9"))
+	assert.True(t, newerVersion("2", "1.12"))
+	assert.True(t, newerVersion("1.0.1", "1"))
+	assert.False(t, newerVersion("1.0", "1"))
+	assert.False(t, newerVersion("1.9", "1.10"))
+
+	//other versions are compared as text
+	assert.True(t, newerVersion("beta", "alpha"))
+}